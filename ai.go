@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"math"
 	"sort"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
@@ -14,100 +17,264 @@ const (
 
 type Move struct {
 	X, Y  int
-	Flips [][2]int
+	Flips uint64 // Bitboard of discs flipped by the move
+	Pass  bool   // Set when the player had no legal move
 }
 
 // TTEntry represents an entry in the transposition table
 type TTEntry struct {
 	Depth    int
-	Eval     float64
-	Flag     int // Exact, LowerBound, UpperBound
+	Eval     float64 // From the point of view of the side to move
+	Flag     int     // Exact, LowerBound, UpperBound
 	BestMove Move
 }
 
-var transpositionTable map[uint64]TTEntry
-var ttMutex sync.RWMutex
-
 // MoveKey is a comparable type for use as map keys
 type MoveKey struct {
 	X, Y int
 }
 
-var historyTable map[MoveKey]int
-var killerMoves []MoveKey
+// winScore is added to the disc difference of finished games so that a proven
+// win or loss always outweighs any heuristic evaluation
+const winScore = 1000000
+
+// searchShared is the state shared by all workers of one search
+type searchShared struct {
+	ctx    context.Context // Cancelled when the time budget runs out or the main worker is done
+	engine EngineConfig    // Settings of the AI that is searching
+	seed   uint64          // Varies the evaluation noise between games
+	tt     *transTable
+	nodes  atomic.Int64 // Updated in batches while the workers search
+	start  time.Time
+}
+
+// searchWorker is a single search worker. Killer moves and the history table are
+// private to each worker; only the transposition table is shared.
+type searchWorker struct {
+	shared  *searchShared
+	history map[MoveKey]int
+	killers []MoveKey
+	nodes   int64
+	probes  int64 // Transposition table lookups, added to its statistics when done
+	hits    int64
+	aborted bool
+	report  func(SearchInfo) // Set on the main worker only
+	info    SearchInfo       // Result of the deepest completed iteration
+}
 
-func (g *Game) AIMove() {
+func newSearchWorker(shared *searchShared, maxDepth int) *searchWorker {
+	return &searchWorker{
+		shared:  shared,
+		history: make(map[MoveKey]int),
+		killers: make([]MoveKey, maxDepth+1),
+	}
+}
+
+// AIMove searches the current position and plays the move found by BestMove,
+// passing if there is no legal move
+func (g *Game) AIMove(ctx context.Context) {
+	g.Play(g.BestMove(ctx))
+}
+
+// BestMove searches the current position with the engine configuration of the
+// side to move, deepening until either its depth has been completed or its move
+// time has elapsed, and returns the best move of the deepest completed iteration. If ctx is cancelled first,
+// the best move found so far is returned. The game itself is not changed. A pass
+// move is returned if the side to move has no legal move.
+func (g *Game) BestMove(ctx context.Context) Move {
 	moves := g.ValidMoves(g.current)
 
 	if len(moves) == 0 {
-		g.SwitchTurn()
+		return Move{Pass: true}
+	}
+
+	engine := g.Engine(g.current)
 
-		return
+	// Play from the opening book while it still covers the position
+	if g.book != nil && g.plyCount() < engine.BookDepth {
+		if move, ok := g.book.Choose(g); ok {
+			return move
+		}
 	}
 
-	aiPlayer := g.current
+	ctx, cancel := context.WithTimeout(ctx, engine.MoveTime)
+	defer cancel()
 
-	// Initialize Zobrist hashing and transposition table
-	initZobrist()
-	transpositionTable = make(map[uint64]TTEntry)
-	historyTable = make(map[MoveKey]int)
-	killerMoves = make([]MoveKey, g.difficulty)
+	// Solve the endgame outright once few enough squares are empty. A proven win
+	// or draw is played at once; otherwise the regular search below picks the move
+	// with the best practical chances.
+	if empties := g.CountEmptySquares(); empties <= max(engine.ExactEmpties, engine.WLDEmpties) {
+		wldOnly := empties > engine.ExactEmpties
+		solveCtx, cancelSolve := context.WithTimeout(ctx, engine.MoveTime*3/4)
+		move, score, completed := g.SolveEndgame(solveCtx, wldOnly)
+		cancelSolve()
 
-	// Check for endgame solver activation
-	emptySquares := g.CountEmptySquares()
+		if completed && (!wldOnly || score >= 0) {
+			return move
+		}
+	}
 
-	if emptySquares <= 12 {
-		bestMove := g.EndgameSolver(aiPlayer)
-		g.MakeMove(bestMove, true)
+	return g.searchMove(ctx, *engine, moves)
+}
 
-		return
+// searchMove runs a Lazy SMP search: engine.Threads workers, or g.threads if
+// the engine does not set it, search the same position with iterative deepening
+// and share one transposition table, so that helpers fill in entries the main
+// worker can reuse. Helpers start at staggered depths to spread their work. The
+// result is the best move of the main worker's deepest completed iteration. With
+// a single thread the search is deterministic for a given depth.
+func (g *Game) searchMove(ctx context.Context, engine EngineConfig, moves []Move) Move {
+	threads := g.threads
+	if engine.Threads > 0 {
+		threads = engine.Threads
 	}
 
-	// Iterative deepening with time management
-	var bestMove Move
+	searcher := g.Searcher(g.current)
+	tt, seed := searcher.begin(&engine)
+	defer searcher.end()
 
-	for depth := 1; ; depth++ {
-		var currentBestMove Move
-		var currentBestScore float64 = math.Inf(-1)
+	ctx, stop := context.WithCancel(ctx)
+	shared := &searchShared{ctx: ctx, engine: engine, seed: seed, tt: tt, start: time.Now()}
 
-		// Run minimax at current depth
-		for _, move := range moves {
-			newGame := g.SimulateMove(move, true)
-			score := minimax(newGame, depth-1, math.Inf(-1), math.Inf(1), false, aiPlayer)
+	var wg sync.WaitGroup
 
-			if depth == g.difficulty {
-				break
-			}
+	for i := 1; i < threads; i++ {
+		helperGame := g.Copy()
+		helperMoves := append([]Move(nil), moves...)
+		helper := newSearchWorker(shared, engine.Depth)
+		firstDepth := 1 + i%2
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			helper.iterate(helperGame, helperMoves, firstDepth, engine.Depth)
+		}()
+	}
+
+	mainWorker := newSearchWorker(shared, engine.Depth)
+	mainWorker.report = g.report
+	bestMove := mainWorker.iterate(g, moves, 1, engine.Depth)
 
-			if score > currentBestScore {
-				currentBestScore = score
-				currentBestMove = move
+	stop()
+	wg.Wait()
+
+	// The helpers' last nodes are only counted once they have stopped
+	if threads > 1 && mainWorker.info.Depth > 0 {
+		mainWorker.publish()
+	}
+
+	return bestMove
+}
+
+// iterate deepens from firstDepth to maxDepth until the search is stopped and
+// returns the best move of the deepest completed iteration
+func (s *searchWorker) iterate(g *Game, moves []Move, firstDepth, maxDepth int) Move {
+	defer func() {
+		s.shared.nodes.Add(s.nodes % progressInterval)
+		s.nodes -= s.nodes % progressInterval // All counted in shared.nodes now
+		s.shared.tt.record(s.probes, s.hits)
+	}()
+
+	bestMove := moves[0]
+
+	for depth := firstDepth; depth <= maxDepth; depth++ {
+		move, score, completed := s.searchRoot(g, moves, depth)
+
+		if !completed {
+			// Out of time; the partial iteration cannot be trusted
+			break
+		}
+
+		bestMove = move
+
+		if s.report != nil {
+			s.info = SearchInfo{
+				Depth: depth,
+				Move:  move,
+				Score: score,
+				PV:    g.principalVariation(s.shared.tt, move, depth),
 			}
+			s.publish()
 		}
 
-		if depth == g.difficulty {
+		if s.shared.ctx.Err() != nil {
 			break
 		}
+	}
+
+	return bestMove
+}
+
+// searchRoot runs one iteration of the search at the given depth. The best move
+// of the previous iteration is searched first. It reports false if the iteration
+// was aborted before every root move had been searched.
+func (s *searchWorker) searchRoot(g *Game, moves []Move, depth int) (Move, float64, bool) {
+	hashKey := g.computeZobristHash()
+
+	entry, found := s.shared.tt.Get(hashKey)
+
+	if found {
+		promoteMove(moves, entry.BestMove)
+	}
+
+	alpha := math.Inf(-1)
+	bestMove := moves[0]
+	bestScore := math.Inf(-1)
+
+	for _, move := range moves {
+		newGame := g.SimulateMove(move, true)
+		score := -s.negamax(newGame, depth-1, math.Inf(-1), -alpha, 1)
+
+		if s.aborted {
+			return bestMove, bestScore, false
+		}
+
+		if score > bestScore {
+			bestScore = score
+			bestMove = move
+		}
 
-		bestMove = currentBestMove
+		alpha = math.Max(alpha, bestScore)
 	}
 
-	g.MakeMove(bestMove, true)
+	s.shared.tt.Put(hashKey, TTEntry{Depth: depth, Eval: bestScore, Flag: Exact, BestMove: bestMove})
+
+	return bestMove, bestScore, true
 }
 
-func minimax(game *Game, depth int, alpha, beta float64, maximizing bool, aiPlayer int) float64 {
-	if depth == game.difficulty {
-		return 0 // Return neutral value if maximum depth is reached
+// negamax returns the score of game from the point of view of the side to move
+func (s *searchWorker) negamax(game *Game, depth int, alpha, beta float64, ply int) float64 {
+	s.nodes++
+
+	if s.nodes&1023 == 0 && s.shared.ctx.Err() != nil {
+		s.aborted = true
+	}
+
+	if s.nodes%progressInterval == 0 {
+		s.shared.nodes.Add(progressInterval)
+
+		if s.report != nil && s.info.Depth > 0 {
+			s.publish()
+		}
+	}
+
+	if s.aborted {
+		return 0
 	}
 
 	hashKey := game.computeZobristHash()
 
 	// Transposition table lookup
-	ttMutex.RLock()
+	entry, found := s.shared.tt.Get(hashKey)
+	s.probes++
 
-	if entry, found := transpositionTable[hashKey]; found && entry.Depth >= depth {
-		ttMutex.RUnlock()
+	if found {
+		s.hits++
+	}
 
+	if found && entry.Depth >= depth {
 		switch entry.Flag {
 		case Exact:
 			return entry.Eval
@@ -124,15 +291,15 @@ func minimax(game *Game, depth int, alpha, beta float64, maximizing bool, aiPlay
 		if alpha >= beta {
 			return entry.Eval
 		}
-	} else {
-		ttMutex.RUnlock()
 	}
 
-	if depth == 0 || game.IsGameOver() {
-		eval := game.Evaluate(aiPlayer) // Do a final evaluation
-		ttMutex.Lock()
-		transpositionTable[hashKey] = TTEntry{Depth: depth, Eval: eval, Flag: Exact}
-		ttMutex.Unlock()
+	if game.IsGameOver() {
+		return game.terminalScore(game.current)
+	}
+
+	if depth <= 0 {
+		eval := s.evaluate(game) // Do a final evaluation
+		s.shared.tt.Put(hashKey, TTEntry{Depth: 0, Eval: eval, Flag: Exact})
 
 		return eval
 	}
@@ -141,71 +308,46 @@ func minimax(game *Game, depth int, alpha, beta float64, maximizing bool, aiPlay
 
 	if len(moves) == 0 {
 		game.SwitchTurn()
-		eval := minimax(game, depth-1, alpha, beta, !maximizing, aiPlayer)
+		eval := -s.negamax(game, depth-1, -beta, -alpha, ply+1)
 		game.SwitchTurn()
 
 		return eval
 	}
 
-	orderMoves(game, moves, depth, aiPlayer)
-
-	var value float64
-	var bestMove Move
-	alphaOrig := alpha
+	hashMove := Move{X: -1, Y: -1}
 
-	if maximizing {
-		value = math.Inf(-1)
-
-		for _, move := range moves {
-			if depth == game.difficulty {
-				break
-			}
-
-			newGame := game.SimulateMove(move, true)
-			eval := minimax(newGame, depth-1, alpha, beta, false, aiPlayer)
+	if found && entry.Depth > 0 {
+		hashMove = entry.BestMove
+	}
 
-			if eval > value {
-				value = eval
-				bestMove = move
-			}
+	s.orderMoves(game, moves, ply, hashMove)
 
-			alpha = math.Max(alpha, value)
+	value := math.Inf(-1)
+	var bestMove Move
+	alphaOrig := alpha
 
-			if alpha >= beta {
-				// Beta cutoff
-				moveKey := MoveKey{X: move.X, Y: move.Y}
-				historyTable[moveKey] += depth * depth
-				killerMoves[depth%game.difficulty] = moveKey
+	for _, move := range moves {
+		newGame := game.SimulateMove(move, true)
+		eval := -s.negamax(newGame, depth-1, -beta, -alpha, ply+1)
 
-				break
-			}
+		if s.aborted {
+			return 0
 		}
-	} else {
-		value = math.Inf(1)
 
-		for _, move := range moves {
-			if depth == game.difficulty {
-				break
-			}
-
-			newGame := game.SimulateMove(move, true)
-			eval := minimax(newGame, depth-1, alpha, beta, true, aiPlayer)
-
-			if eval < value {
-				value = eval
-				bestMove = move
-			}
+		if eval > value {
+			value = eval
+			bestMove = move
+		}
 
-			beta = math.Min(beta, value)
+		alpha = math.Max(alpha, value)
 
-			if alpha >= beta {
-				// Alpha cutoff
-				moveKey := MoveKey{X: move.X, Y: move.Y}
-				historyTable[moveKey] += depth * depth
-				killerMoves[depth%game.difficulty] = moveKey
+		if alpha >= beta {
+			// Beta cutoff
+			moveKey := MoveKey{X: move.X, Y: move.Y}
+			s.history[moveKey] += depth * depth
+			s.killers[ply%len(s.killers)] = moveKey
 
-				break
-			}
+			break
 		}
 	}
 
@@ -220,14 +362,61 @@ func minimax(game *Game, depth int, alpha, beta float64, maximizing bool, aiPlay
 		flag = Exact
 	}
 
-	ttMutex.Lock()
-	transpositionTable[hashKey] = TTEntry{Depth: depth, Eval: value, Flag: flag, BestMove: bestMove}
-	ttMutex.Unlock()
+	s.shared.tt.Put(hashKey, TTEntry{Depth: depth, Eval: value, Flag: flag, BestMove: bestMove})
 
 	return value
 }
 
-func orderMoves(game *Game, moves []Move, depth int, aiPlayer int) {
+// publish reports the last completed iteration with up to date node counts
+func (s *searchWorker) publish() {
+	info := s.info
+	info.Nodes = s.shared.nodes.Load() + s.nodes%progressInterval
+	info.Elapsed = time.Since(s.shared.start)
+
+	s.report(info)
+}
+
+// evaluate scores game for the side to move with the engine's weights, adding the
+// engine's random noise
+func (s *searchWorker) evaluate(game *Game) float64 {
+	eval := game.EvaluateWith(game.current, &s.shared.engine.Weights).TotalScore
+
+	if s.shared.engine.Randomness > 0 {
+		eval += s.shared.engine.Randomness * noise(game.computeZobristHash()^s.shared.seed)
+	}
+
+	return eval
+}
+
+// terminalScore scores a finished game for player
+func (g *Game) terminalScore(player int) float64 {
+	diff := popCount(g.board.Discs(player)) - popCount(g.board.Discs(Opponent(player)))
+
+	switch {
+	case diff > 0:
+		return float64(winScore + diff)
+	case diff < 0:
+		return float64(-winScore + diff)
+	default:
+		return 0
+	}
+}
+
+// promoteMove moves the given move to the front of moves, keeping the order of the rest
+func promoteMove(moves []Move, move Move) {
+	for i, m := range moves {
+		if m.X == move.X && m.Y == move.Y {
+			copy(moves[1:i+1], moves[:i])
+			moves[0] = m
+
+			return
+		}
+	}
+}
+
+// orderMoves sorts moves best-first: the hash move, then the killer move for this
+// ply, then by history score and finally by static evaluation
+func (s *searchWorker) orderMoves(game *Game, moves []Move, ply int, hashMove Move) {
 	type MoveEval struct {
 		move    Move
 		moveKey MoveKey
@@ -235,10 +424,11 @@ func orderMoves(game *Game, moves []Move, depth int, aiPlayer int) {
 	}
 
 	moveEvals := make([]MoveEval, len(moves))
+	player := game.current
 
 	for i, move := range moves {
 		newGame := game.SimulateMove(move, false)
-		eval := newGame.Evaluate(aiPlayer)
+		eval := newGame.EvaluateWith(player, &s.shared.engine.Weights).TotalScore
 		moveKey := MoveKey{X: move.X, Y: move.Y}
 		moveEvals[i] = MoveEval{
 			move:    move,
@@ -247,11 +437,21 @@ func orderMoves(game *Game, moves []Move, depth int, aiPlayer int) {
 		}
 	}
 
+	hashMoveKey := MoveKey{X: hashMove.X, Y: hashMove.Y}
+	killerMoveKey := s.killers[ply%len(s.killers)]
+
 	// Prioritize moves based on safety and evaluation score
 	sort.Slice(moveEvals, func(i, j int) bool {
-		// Killer move priority
-		killerMoveKey := killerMoves[depth%game.difficulty]
+		// Hash move from an earlier iteration first
+		if moveEvals[i].moveKey == hashMoveKey {
+			return true
+		}
 
+		if moveEvals[j].moveKey == hashMoveKey {
+			return false
+		}
+
+		// Killer move priority
 		if moveEvals[i].moveKey == killerMoveKey {
 			return true
 		}
@@ -261,8 +461,8 @@ func orderMoves(game *Game, moves []Move, depth int, aiPlayer int) {
 		}
 
 		// History heuristic
-		hi := historyTable[moveEvals[i].moveKey]
-		hj := historyTable[moveEvals[j].moveKey]
+		hi := s.history[moveEvals[i].moveKey]
+		hj := s.history[moveEvals[j].moveKey]
 
 		if hi != hj {
 			return hi > hj
@@ -299,105 +499,10 @@ func isEdgePosition(x, y int) bool {
 	return false
 }
 
-func (g *Game) EndgameSolver(aiPlayer int) Move {
-	// Perform exhaustive search to the end of the game
-	moves := g.ValidMoves(g.current)
-	if len(moves) == 0 {
-		g.SwitchTurn()
-
-		return g.EndgameSolver(aiPlayer)
-	}
-
-	var bestMove Move
-	var bestScore float64 = math.Inf(-1)
-
-	for _, move := range moves {
-		newGame := g.SimulateMove(move, true)
-		score := minimaxEndgame(newGame, math.Inf(-1), math.Inf(1), false, aiPlayer)
-
-		if score > bestScore {
-			bestScore = score
-			bestMove = move
-		}
-	}
-
-	return bestMove
-}
-
-func minimaxEndgame(game *Game, alpha, beta float64, maximizing bool, aiPlayer int) float64 {
-	if game.IsGameOver() {
-		return game.EvaluateEndgame(aiPlayer)
-	}
-
-	moves := game.ValidMoves(game.current)
-
-	if len(moves) == 0 {
-		game.SwitchTurn()
-		eval := minimaxEndgame(game, alpha, beta, !maximizing, aiPlayer)
-		game.SwitchTurn()
-
-		return eval
-	}
-
-	if maximizing {
-		value := math.Inf(-1)
-
-		for _, move := range moves {
-			newGame := game.SimulateMove(move, true)
-			eval := minimaxEndgame(newGame, alpha, beta, false, aiPlayer)
-			value = math.Max(value, eval)
-			alpha = math.Max(alpha, value)
-
-			if alpha >= beta {
-				break
-			}
-		}
-
-		return value
-	} else {
-		value := math.Inf(1)
-
-		for _, move := range moves {
-			newGame := game.SimulateMove(move, true)
-			eval := minimaxEndgame(newGame, alpha, beta, true, aiPlayer)
-			value = math.Min(value, eval)
-			beta = math.Min(beta, value)
-
-			if alpha >= beta {
-				break
-			}
-		}
-
-		return value
-	}
-}
-
-func (g *Game) EvaluateEndgame(aiPlayer int) float64 {
-	// If the game is over, evaluate based on the number of pieces and return a high score for winning, low score for losing and 0 for draw
-	winner := g.GetWinner()
-
-	if winner == aiPlayer {
-		return 100
-	} else if winner == Opponent(aiPlayer) {
-		return -100
-	} else {
-		return 0
-	}
-}
-
 func (g *Game) CountEmptySquares() int {
-	count := 0
-	for x := 0; x < BoardSize; x++ {
-		for y := 0; y < BoardSize; y++ {
-			if g.board[x][y] == Blank {
-				count++
-			}
-		}
-	}
-
-	return count
+	return popCount(g.board.Empty())
 }
 
 func (g *Game) IsGameOver() bool {
-	return len(g.ValidMoves(Black)) == 0 && len(g.ValidMoves(White)) == 0
+	return legalMoves(g.board.black, g.board.white) == 0 && legalMoves(g.board.white, g.board.black) == 0
 }
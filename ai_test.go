@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// searchPositions are openings the AI is tested on
+var searchPositions = []string{"", "f5d6c3d3c4", "f5f6e6f4e3c5c4e7", "c4e3f6e6f5c5f4g6f7d3"}
+
+// deterministicGame sets up a game whose AI searches with the given number of
+// threads, no book and a fixed depth, which with a single thread makes its
+// moves repeatable
+func deterministicGame(t *testing.T, transcript string, depth, threads int) *Game {
+	g := NewGame()
+	g.book = nil
+	g.threads = threads
+
+	if err := g.LoadTranscript(transcript); err != nil {
+		t.Fatalf("%s: %v", transcript, err)
+	}
+
+	engine := g.Engine(g.current)
+	engine.Depth = depth
+	engine.MoveTime = time.Hour
+	engine.ExactEmpties, engine.WLDEmpties = 0, 0
+
+	return g
+}
+
+func TestBestMoveDeterministic(t *testing.T) {
+	for _, transcript := range searchPositions {
+		first := deterministicGame(t, transcript, 4, 1).BestMove(context.Background())
+		second := deterministicGame(t, transcript, 4, 1).BestMove(context.Background())
+
+		if first != second {
+			t.Errorf("%s: BestMove = %s, then %s", transcript, SquareName(first.X, first.Y), SquareName(second.X, second.Y))
+		}
+	}
+}
+
+func TestBestMoveParallel(t *testing.T) {
+	for _, transcript := range searchPositions {
+		g := deterministicGame(t, transcript, 5, 4)
+		move := g.BestMove(context.Background())
+
+		if move.Pass || g.Flips(move.X, move.Y, g.current) != move.Flips || move.Flips == 0 {
+			t.Errorf("%s: BestMove %s is not a legal move", transcript, SquareName(move.X, move.Y))
+		}
+	}
+}
+
+func TestBestMovePasses(t *testing.T) {
+	// White has no legal move
+	g := NewGame()
+	g.board = &Board{black: squareBit(3, 3), white: (1<<60 - 1) &^ squareBit(3, 3)}
+	g.current = White
+
+	if move := g.BestMove(context.Background()); !move.Pass {
+		t.Errorf("BestMove without a legal move = %s, want a pass", SquareName(move.X, move.Y))
+	}
+}
+
+func TestBestMoveCancelled(t *testing.T) {
+	g := deterministicGame(t, "f5d6c3d3c4", BoardSize*BoardSize, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	move := g.BestMove(ctx)
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("BestMove took %v after its context was cancelled", elapsed)
+	}
+
+	if move.Pass || g.Flips(move.X, move.Y, g.current) == 0 {
+		t.Errorf("BestMove = %+v after cancelling, want a legal move", move)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"math"
+	"sort"
+)
+
+// MoveScore is the search score of one legal move from the point of view of the
+// player making it
+type MoveScore struct {
+	Move   Move
+	Score  float64
+	Depth  int  // Depth of the deepest completed iteration
+	Solved bool // Score is the exact final disc difference
+}
+
+// AnalyzeMoves scores every legal move of the side to move (multi-PV), best
+// first, without playing any of them. Within the endgame solver's range the
+// scores are exact disc differences; otherwise each move is searched with a full
+// window by iterative deepening until the depth or move time of the side to
+// move's engine configuration. If ctx is
+// cancelled the scores of the deepest completed iteration are returned.
+func (g *Game) AnalyzeMoves(ctx context.Context) []MoveScore {
+	moves := g.ValidMoves(g.current)
+
+	if len(moves) == 0 {
+		return nil
+	}
+
+	engine := g.Engine(g.current)
+
+	ctx, cancel := context.WithTimeout(ctx, engine.MoveTime)
+	defer cancel()
+
+	if g.CountEmptySquares() <= engine.ExactEmpties {
+		if scores, completed := g.solveMoves(ctx, moves); completed {
+			return scores
+		}
+	}
+
+	// Fall back to the static evaluation if not even depth 1 completes
+	scores := make([]MoveScore, len(moves))
+	for i, move := range moves {
+		scores[i] = MoveScore{Move: move, Score: g.SimulateMove(move, false).EvaluateWith(g.current, &engine.Weights).TotalScore}
+	}
+
+	sortMoveScores(scores)
+
+	searcher := g.Searcher(g.current)
+	tt, seed := searcher.begin(engine)
+	defer searcher.end()
+
+	s := newSearchWorker(&searchShared{ctx: ctx, engine: *engine, seed: seed, tt: tt}, engine.Depth)
+	defer func() {
+		tt.record(s.probes, s.hits)
+	}()
+
+	for depth := 1; depth <= engine.Depth; depth++ {
+		iteration := make([]MoveScore, 0, len(scores))
+
+		// Search in the order of the previous iteration so the transposition table helps most
+		for _, previous := range scores {
+			newGame := g.SimulateMove(previous.Move, true)
+			score := -s.negamax(newGame, depth-1, math.Inf(-1), math.Inf(1), 1)
+
+			if s.aborted {
+				return scores
+			}
+
+			iteration = append(iteration, MoveScore{Move: previous.Move, Score: score, Depth: depth})
+		}
+
+		sortMoveScores(iteration)
+		scores = iteration
+	}
+
+	return scores
+}
+
+// solveMoves computes the exact final disc difference of every move
+func (g *Game) solveMoves(ctx context.Context, moves []Move) ([]MoveScore, bool) {
+	s := &endgameSearch{ctx: ctx}
+	own, opp := g.board.Discs(g.current), g.board.Discs(Opponent(g.current))
+	limit := BoardSize*BoardSize + 1
+	scores := make([]MoveScore, 0, len(moves))
+
+	for _, move := range moves {
+		placed := squareBit(move.X, move.Y) | move.Flips
+		score := -s.solve(opp&^move.Flips, own|placed, -limit, limit, false)
+
+		if s.aborted {
+			return nil, false
+		}
+
+		scores = append(scores, MoveScore{Move: move, Score: float64(score), Depth: g.CountEmptySquares(), Solved: true})
+	}
+
+	sortMoveScores(scores)
+
+	return scores, true
+}
+
+func sortMoveScores(scores []MoveScore) {
+	sort.SliceStable(scores, func(i, j int) bool {
+		return scores[i].Score > scores[j].Score
+	})
+}
@@ -0,0 +1,110 @@
+package main
+
+import "math/bits"
+
+// Squares are numbered y*BoardSize + x, so bit 0 is a1 (top left) and bit 63 is h8.
+const (
+	notAFile uint64 = 0xfefefefefefefefe
+	notHFile uint64 = 0x7f7f7f7f7f7f7f7f
+
+	cornerMask uint64 = 0x8100000000000081
+	topEdge    uint64 = 0x00000000000000ff
+	bottomEdge uint64 = 0xff00000000000000
+	leftEdge   uint64 = 0x0101010101010101
+	rightEdge  uint64 = 0x8080808080808080
+)
+
+// shiftDir moves every bit one step in direction d, dropping bits that would wrap around an edge
+func shiftDir(b uint64, d int) uint64 {
+	switch d {
+	case 0: // north-west
+		return (b >> 9) & notHFile
+	case 1: // north
+		return b >> 8
+	case 2: // north-east
+		return (b >> 7) & notAFile
+	case 3: // west
+		return (b >> 1) & notHFile
+	case 4: // east
+		return (b << 1) & notAFile
+	case 5: // south-west
+		return (b << 7) & notHFile
+	case 6: // south
+		return b << 8
+	default: // south-east
+		return (b << 9) & notAFile
+	}
+}
+
+// squareBit returns the bitboard with only (x, y) set
+func squareBit(x, y int) uint64 {
+	return 1 << uint(y*BoardSize+x)
+}
+
+// squareXY converts a square index back to board coordinates
+func squareXY(sq int) (int, int) {
+	return sq % BoardSize, sq / BoardSize
+}
+
+// legalMoves returns the set of empty squares where own may play against opp
+func legalMoves(own, opp uint64) uint64 {
+	empty := ^(own | opp)
+
+	var moves uint64
+
+	for d := 0; d < 8; d++ {
+		x := shiftDir(own, d) & opp
+		x |= shiftDir(x, d) & opp
+		x |= shiftDir(x, d) & opp
+		x |= shiftDir(x, d) & opp
+		x |= shiftDir(x, d) & opp
+		x |= shiftDir(x, d) & opp
+		moves |= shiftDir(x, d) & empty
+	}
+
+	return moves
+}
+
+// flipsFor returns the opponent discs flipped when own plays on square sq
+func flipsFor(own, opp uint64, sq int) uint64 {
+	move := uint64(1) << uint(sq)
+
+	var flipped uint64
+
+	for d := 0; d < 8; d++ {
+		var line uint64
+
+		x := shiftDir(move, d)
+		for x&opp != 0 {
+			line |= x
+			x = shiftDir(x, d)
+		}
+
+		if x&own != 0 {
+			flipped |= line
+		}
+	}
+
+	return flipped
+}
+
+// neighbours returns every square adjacent to a bit in b
+func neighbours(b uint64) uint64 {
+	var n uint64
+
+	for d := 0; d < 8; d++ {
+		n |= shiftDir(b, d)
+	}
+
+	return n
+}
+
+// popCount returns the number of set bits
+func popCount(b uint64) int {
+	return bits.OnesCount64(b)
+}
+
+// bitIndex returns the index of the lowest set bit
+func bitIndex(b uint64) int {
+	return bits.TrailingZeros64(b)
+}
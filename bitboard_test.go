@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestPerft(t *testing.T) {
+	// Published move path counts from the initial position, passes counted as plies
+	want := []int64{1, 4, 12, 56, 244, 1396, 8200, 55092, 390216, 3005288}
+
+	for depth, count := range want {
+		if got := NewGame().Perft(depth); got != count {
+			t.Errorf("Perft(%d) = %d, want %d", depth, got, count)
+		}
+	}
+}
+
+func TestFlips(t *testing.T) {
+	g := NewGame()
+
+	tests := []struct {
+		x, y   int
+		player int
+		flips  uint64
+	}{
+		{5, 4, Black, squareBit(4, 4)}, // f5 flips e5
+		{3, 2, Black, squareBit(3, 3)}, // d3 flips d4
+		{4, 2, Black, 0},               // e3 is next to Black's own disc
+		{3, 3, Black, 0},               // d4 is occupied
+		{4, 2, White, squareBit(4, 3)}, // e3 flips e4
+		{2, 4, White, squareBit(3, 4)}, // c5 flips d5
+		{0, 0, White, 0},               // a1 touches no disc
+	}
+
+	for _, test := range tests {
+		if got := g.Flips(test.x, test.y, test.player); got != test.flips {
+			t.Errorf("Flips(%d, %d) for %s = %#x, want %#x", test.x, test.y, g.PlayerName(test.player), got, test.flips)
+		}
+	}
+}
@@ -1,14 +1,20 @@
 // board.go
 package main
 
-type Board [BoardSize][BoardSize]int
+// Board holds one bitboard per colour; see bitboard.go for the square numbering
+type Board struct {
+	black uint64
+	white uint64
+}
 
 // NewBoard initializes the board with starting positions
 func NewBoard() *Board {
 	b := &Board{}
 	mid := BoardSize / 2
-	b[mid-1][mid-1], b[mid][mid] = White, White
-	b[mid-1][mid], b[mid][mid-1] = Black, Black
+	b.Set(mid-1, mid-1, White)
+	b.Set(mid, mid, White)
+	b.Set(mid-1, mid, Black)
+	b.Set(mid, mid-1, Black)
 
 	return b
 }
@@ -20,19 +26,50 @@ func (b *Board) Copy() *Board {
 	return &newBoard
 }
 
-func (g *Game) GetWinner() int {
-	blackCount, whiteCount := 0, 0
-
-	for x := 0; x < BoardSize; x++ {
-		for y := 0; y < BoardSize; y++ {
-			if g.board[x][y] == Black {
-				blackCount++
-			} else if g.board[x][y] == White {
-				whiteCount++
-			}
-		}
+// Get returns the piece at (x, y)
+func (b *Board) Get(x, y int) int {
+	bit := squareBit(x, y)
+
+	if b.black&bit != 0 {
+		return Black
+	} else if b.white&bit != 0 {
+		return White
+	}
+
+	return Blank
+}
+
+// Set places piece at (x, y), clearing whatever was there
+func (b *Board) Set(x, y, piece int) {
+	bit := squareBit(x, y)
+	b.black &^= bit
+	b.white &^= bit
+
+	switch piece {
+	case Black:
+		b.black |= bit
+	case White:
+		b.white |= bit
 	}
-	
+}
+
+// Discs returns the bitboard of the given player's discs
+func (b *Board) Discs(player int) uint64 {
+	if player == Black {
+		return b.black
+	}
+
+	return b.white
+}
+
+// Empty returns the bitboard of empty squares
+func (b *Board) Empty() uint64 {
+	return ^(b.black | b.white)
+}
+
+func (g *Game) GetWinner() int {
+	blackCount, whiteCount := g.GetScore()
+
 	if blackCount > whiteCount {
 		return Black
 	} else if whiteCount > blackCount {
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"math/bits"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+//go:embed book.txt
+var defaultBookText string
+
+// OpeningBook maps positions to weighted moves. Positions are stored under the
+// smallest Zobrist hash of their eight symmetric variants, with moves transformed
+// the same way, so one entry covers every rotation and reflection of an opening.
+type OpeningBook struct {
+	entries map[uint64][]bookEntry
+}
+
+type bookEntry struct {
+	square int // In the normalized orientation
+	weight int
+}
+
+// BookMove is a book move for the actual position
+type BookMove struct {
+	Move   Move
+	Weight int
+}
+
+// DefaultBook returns the opening book built into the binary
+func DefaultBook() *OpeningBook {
+	book, err := LoadBook(strings.NewReader(defaultBookText))
+	if err != nil {
+		panic(fmt.Sprintf("built-in opening book: %v", err))
+	}
+
+	return book
+}
+
+// LoadBook reads a book where each line holds a transcript from the initial
+// position and an optional weight. Blank lines and text after '#' are ignored.
+func LoadBook(r io.Reader) (*OpeningBook, error) {
+	book := &OpeningBook{entries: make(map[uint64][]bookEntry)}
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		weight := 1
+		if len(fields) > 1 {
+			w, err := strconv.Atoi(fields[1])
+			if err != nil || w <= 0 {
+				return nil, fmt.Errorf("line %d: invalid weight %q", lineNumber, fields[1])
+			}
+
+			weight = w
+		}
+
+		if err := book.addLine(fields[0], weight); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return book, nil
+}
+
+// addLine adds every position and move along a transcript
+func (b *OpeningBook) addLine(transcript string, weight int) error {
+	g := NewGame()
+
+	if len(transcript)%2 != 0 {
+		return fmt.Errorf("transcript has odd length %d", len(transcript))
+	}
+
+	for i := 0; i < len(transcript); i += 2 {
+		x, y, err := ParseSquare(transcript[i : i+2])
+		if err != nil {
+			return err
+		}
+
+		g.passIfStuck()
+
+		key, transform := normalizedHash(g.board, g.current)
+		b.add(key, transformSquare(y*BoardSize+x, transform), weight)
+
+		if err := g.playSquare(x, y); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *OpeningBook) add(key uint64, square, weight int) {
+	entries := b.entries[key]
+
+	for i := range entries {
+		if entries[i].square == square {
+			entries[i].weight += weight
+
+			return
+		}
+	}
+
+	b.entries[key] = append(entries, bookEntry{square: square, weight: weight})
+}
+
+// Size returns the number of positions in the book
+func (b *OpeningBook) Size() int {
+	return len(b.entries)
+}
+
+// Moves returns the book moves for the current position of g
+func (b *OpeningBook) Moves(g *Game) []BookMove {
+	key, transform := normalizedHash(g.board, g.current)
+	entries := b.entries[key]
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	moves := make([]BookMove, 0, len(entries))
+
+	for _, entry := range entries {
+		x, y := squareXY(untransformSquare(entry.square, transform))
+
+		if flips := g.Flips(x, y, g.current); flips != 0 {
+			moves = append(moves, BookMove{Move: Move{X: x, Y: y, Flips: flips}, Weight: entry.weight})
+		}
+	}
+
+	return moves
+}
+
+// Choose picks one of the book moves for g at random, weighted by popularity
+func (b *OpeningBook) Choose(g *Game) (Move, bool) {
+	moves := b.Moves(g)
+	total := 0
+
+	for _, m := range moves {
+		total += m.Weight
+	}
+
+	if total == 0 {
+		return Move{}, false
+	}
+
+	pick := rand.Intn(total)
+
+	for _, m := range moves {
+		if pick < m.Weight {
+			return m.Move, true
+		}
+
+		pick -= m.Weight
+	}
+
+	return moves[len(moves)-1].Move, true
+}
+
+// Board symmetries. A transform is a bit set of: 1 mirror left-right,
+// 2 flip top-bottom, 4 transpose along the a1-h8 diagonal, applied in that order
+// from the highest bit down.
+
+func mirrorHorizontal(b uint64) uint64 {
+	const k1, k2, k4 = 0x5555555555555555, 0x3333333333333333, 0x0f0f0f0f0f0f0f0f
+	b = ((b >> 1) & k1) | ((b & k1) << 1)
+	b = ((b >> 2) & k2) | ((b & k2) << 2)
+	b = ((b >> 4) & k4) | ((b & k4) << 4)
+
+	return b
+}
+
+func flipVertical(b uint64) uint64 {
+	return bits.ReverseBytes64(b)
+}
+
+func transpose(b uint64) uint64 {
+	const k1, k2, k4 = 0x5500550055005500, 0x3333000033330000, 0x0f0f0f0f00000000
+	t := k4 & (b ^ (b << 28))
+	b ^= t ^ (t >> 28)
+	t = k2 & (b ^ (b << 14))
+	b ^= t ^ (t >> 14)
+	t = k1 & (b ^ (b << 7))
+	b ^= t ^ (t >> 7)
+
+	return b
+}
+
+func transformBits(b uint64, transform int) uint64 {
+	if transform&4 != 0 {
+		b = transpose(b)
+	}
+	if transform&2 != 0 {
+		b = flipVertical(b)
+	}
+	if transform&1 != 0 {
+		b = mirrorHorizontal(b)
+	}
+
+	return b
+}
+
+func transformSquare(sq, transform int) int {
+	return bits.TrailingZeros64(transformBits(1<<uint(sq), transform))
+}
+
+// untransformSquare finds the square that transform maps onto sq
+func untransformSquare(sq, transform int) int {
+	for from := 0; from < BoardSize*BoardSize; from++ {
+		if transformSquare(from, transform) == sq {
+			return from
+		}
+	}
+
+	return sq
+}
+
+// normalizedHash returns the smallest hash among the symmetric variants of the
+// position, and the transform that produced it
+func normalizedHash(b *Board, current int) (uint64, int) {
+	var best uint64
+	bestTransform := -1
+
+	for t := 0; t < 8; t++ {
+		variant := Board{black: transformBits(b.black, t), white: transformBits(b.white, t)}
+
+		if h := hashBoard(&variant, current); bestTransform < 0 || h < best {
+			best, bestTransform = h, t
+		}
+	}
+
+	return best, bestTransform
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBookSymmetry(t *testing.T) {
+	book, err := LoadBook(strings.NewReader("f5d6 3\nf5f6\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every first move is a rotation or reflection of f5, so the book answers
+	// each with the matching images of d6 and f6
+	tests := []struct {
+		opening string
+		want    []string // Moves with their weights, sorted
+	}{
+		{"", []string{"f5 4"}},
+		{"f5", []string{"d6 3", "f6 1"}},
+		{"d3", []string{"c3 1", "c5 3"}},
+		{"c4", []string{"c3 1", "e3 3"}},
+		{"e6", []string{"f4 3", "f6 1"}},
+		{"f5d6", nil},
+		{"f5f4", nil},
+	}
+
+	for _, test := range tests {
+		g := NewGame()
+		if err := g.LoadTranscript(test.opening); err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, m := range book.Moves(g) {
+			got = append(got, SquareName(m.Move.X, m.Move.Y)+" "+strconv.Itoa(m.Weight))
+		}
+
+		sort.Strings(got)
+
+		if strings.Join(got, ", ") != strings.Join(test.want, ", ") {
+			t.Errorf("book moves after %q = %v, want %v", test.opening, got, test.want)
+		}
+	}
+}
+
+func TestTransformSquare(t *testing.T) {
+	for transform := 0; transform < 8; transform++ {
+		seen := map[int]bool{}
+
+		for sq := 0; sq < BoardSize*BoardSize; sq++ {
+			image := transformSquare(sq, transform)
+			seen[image] = true
+
+			if untransformSquare(image, transform) != sq {
+				t.Errorf("transform %d: untransformSquare(transformSquare(%d)) = %d", transform, sq, untransformSquare(image, transform))
+			}
+		}
+
+		if len(seen) != BoardSize*BoardSize {
+			t.Errorf("transform %d maps the board onto %d squares", transform, len(seen))
+		}
+	}
+}
+
+func TestLoadBookErrors(t *testing.T) {
+	for _, text := range []string{"f5d", "f5d6 0", "f5d6 x", "f5a1", "f5z9"} {
+		if _, err := LoadBook(strings.NewReader(text)); err == nil {
+			t.Errorf("LoadBook(%q) succeeded", text)
+		}
+	}
+}
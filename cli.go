@@ -0,0 +1,466 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+// command is a subcommand of the reversi binary
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+// commands lists the subcommands; the first one runs when none is given
+var commands = []command{
+	{"play", "play in the terminal UI", runPlay},
+	{"analyze", "score every legal move of a position", runAnalyze},
+	{"solve", "solve the endgame of a position", runSolve},
+	{"selfplay", "let two engines play each other", runSelfPlay},
+	{"tournament", "play a match between two engines and estimate their Elo difference", runTournament},
+	{"nboard", "run as an engine for the NBoard GUI over stdin and stdout", runNBoard},
+	{"gtp", "run as an engine speaking the Go Text Protocol over stdin and stdout", runGTP},
+	{"serve", "serve games over an HTTP JSON API", runServe},
+	{"perft", "count the move sequences up to a depth", runPerft},
+	{"bench", "measure the search speed on fixed positions", runBench},
+}
+
+// runCommand runs the subcommand named by the first argument
+func runCommand(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return commands[0].run(args)
+	}
+
+	for _, c := range commands {
+		if c.name == args[0] {
+			if err := c.run(args[1:]); err != nil {
+				return fmt.Errorf("%s: %w", c.name, err)
+			}
+
+			return nil
+		}
+	}
+
+	if args[0] != "help" {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+	}
+
+	fmt.Fprintln(os.Stderr, "Usage: reversi [command] [flags]\n\nCommands:")
+
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", c.name, c.usage)
+	}
+
+	fmt.Fprintln(os.Stderr, "\nRun 'reversi <command> -h' for the flags of a command.")
+
+	if args[0] != "help" {
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+
+	return nil
+}
+
+// positionFlags holds the flags shared by the commands that work on a position
+type positionFlags struct {
+	start  *string
+	format *string
+}
+
+func addPositionFlags(flags *flag.FlagSet) positionFlags {
+	return positionFlags{
+		start:  flags.String("start", "", "starting position: a move transcript from the initial position such as f5d6c3, or a board string of 64 X/O/- squares and the side to move"),
+		format: flags.String("format", "text", "output format: text or json"),
+	}
+}
+
+// game returns a new game set up at the starting position
+func (p positionFlags) game() (*Game, error) {
+	g := NewGame()
+
+	if err := g.LoadStart(*p.start); err != nil {
+		return nil, fmt.Errorf("start position: %w", err)
+	}
+
+	return g, nil
+}
+
+// output writes value as JSON, or as text through the text function
+func (p positionFlags) output(value any, text func(w io.Writer)) error {
+	switch *p.format {
+	case "json":
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(value)
+	case "text":
+		text(os.Stdout)
+
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q", *p.format)
+	}
+}
+
+// parsePlayer reads a player flag: "human" or an engine specification
+func parsePlayer(spec string) (bool, EngineConfig, error) {
+	if strings.EqualFold(spec, "human") {
+		return false, DefaultDifficulty.Config(), nil
+	}
+
+	config, err := ParseEngineSpec(spec)
+
+	return err == nil, config, err
+}
+
+// interruptible returns a context that is cancelled on Ctrl-C or after timeout,
+// if timeout is positive
+func interruptible(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+
+	if timeout <= 0 {
+		return ctx, stop
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+func runPlay(args []string) error {
+	flags := flag.NewFlagSet("play", flag.ContinueOnError)
+	black := flags.String("black", "human", "Black player: human or a difficulty name")
+	white := flags.String("white", "medium", "White player: human or a difficulty name")
+	timeControl := flags.String("time", "none", "time control, e.g. 5m, 3m+2s or 1m+3x10s")
+	start := flags.String("start", "", "starting position: a move transcript or a board string")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	options := DefaultUIOptions
+
+	for _, side := range []struct {
+		spec       string
+		playerType *int
+	}{{*black, &options.Black}, {*white, &options.White}} {
+		index := -1
+
+		for i, name := range playerTypeNames() {
+			if strings.EqualFold(name, side.spec) || strings.EqualFold(name, "AI "+side.spec) {
+				index = i
+			}
+		}
+
+		if index < 0 {
+			return fmt.Errorf("unknown player %q, expected human or one of %s", side.spec, strings.Join(difficultyNames(), ", "))
+		}
+
+		*side.playerType = index
+	}
+
+	tc, err := ParseTimeControl(*timeControl)
+	if err != nil {
+		return err
+	}
+
+	options.TimeControl = tc
+
+	g := NewGame()
+
+	if err := g.LoadStart(*start); err != nil {
+		return fmt.Errorf("start position: %w", err)
+	}
+
+	g.StartUI(options)
+
+	return nil
+}
+
+func runAnalyze(args []string) error {
+	flags := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	position := addPositionFlags(flags)
+	engineSpec := flags.String("engine", "hard", "engine: a difficulty name with optional overrides, e.g. hard,depth=7,time=5s")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	g, err := position.game()
+	if err != nil {
+		return err
+	}
+
+	engine, err := ParseEngineSpec(*engineSpec)
+	if err != nil {
+		return err
+	}
+
+	g.SetEngine(g.current, engine)
+
+	ctx, cancel := interruptible(0)
+	defer cancel()
+
+	type moveJSON struct {
+		Move   string  `json:"move"`
+		Score  float64 `json:"score"`
+		Depth  int     `json:"depth"`
+		Solved bool    `json:"solved"`
+	}
+
+	scores := g.AnalyzeMoves(ctx)
+	moves := make([]moveJSON, len(scores))
+
+	for i, s := range scores {
+		moves[i] = moveJSON{SquareName(s.Move.X, s.Move.Y), s.Score, s.Depth, s.Solved}
+	}
+
+	return position.output(moves, func(w io.Writer) {
+		fmt.Fprintf(w, "%s\n%s to move\n", g.Position(), g.PlayerName(g.current))
+
+		if len(moves) == 0 {
+			fmt.Fprintln(w, "No legal moves")
+		}
+
+		for _, s := range scores {
+			fmt.Fprintf(w, "%s  %6s  depth %d\n", SquareName(s.Move.X, s.Move.Y), formatHintScore(s), s.Depth)
+		}
+	})
+}
+
+func runSolve(args []string) error {
+	flags := flag.NewFlagSet("solve", flag.ContinueOnError)
+	position := addPositionFlags(flags)
+	wldOnly := flags.Bool("wld", false, "only prove win, draw or loss")
+	timeout := flags.Duration("timeout", 0, "give up after this long; 0 for no limit")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	g, err := position.game()
+	if err != nil {
+		return err
+	}
+
+	if len(g.ValidMoves(g.current)) == 0 {
+		return fmt.Errorf("%s has no legal move", g.PlayerName(g.current))
+	}
+
+	ctx, cancel := interruptible(*timeout)
+	defer cancel()
+
+	var info SearchInfo
+	g.progress = func(i SearchInfo) {
+		info = i
+	}
+
+	move, score, completed := g.SolveEndgame(ctx, *wldOnly)
+	if !completed {
+		return fmt.Errorf("search stopped before the position was solved")
+	}
+
+	result := struct {
+		Move    string  `json:"move"`
+		Score   int     `json:"score"`
+		WLDOnly bool    `json:"wldOnly"`
+		Empties int     `json:"empties"`
+		Nodes   int64   `json:"nodes"`
+		Seconds float64 `json:"seconds"`
+	}{SquareName(move.X, move.Y), score, *wldOnly, g.CountEmptySquares(), info.Nodes, info.Elapsed.Seconds()}
+
+	return position.output(result, func(w io.Writer) {
+		outcome := fmt.Sprintf("%+d", score)
+		if *wldOnly {
+			outcome = map[int]string{-1: "loss", 0: "draw", 1: "win"}[score]
+		}
+
+		fmt.Fprintf(w, "%s\n%s to move, %d empties\nBest move %s, %s\n%d nodes in %.2fs\n",
+			g.Position(), g.PlayerName(g.current), result.Empties, result.Move, outcome, result.Nodes, result.Seconds)
+	})
+}
+
+func runSelfPlay(args []string) error {
+	flags := flag.NewFlagSet("selfplay", flag.ContinueOnError)
+	position := addPositionFlags(flags)
+	black := flags.String("black", "hard", "Black engine: a difficulty name with optional overrides")
+	white := flags.String("white", "hard", "White engine: a difficulty name with optional overrides")
+	games := flags.Int("games", 1, "number of games to play")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	blackEngine, err := ParseEngineSpec(*black)
+	if err != nil {
+		return fmt.Errorf("black: %w", err)
+	}
+
+	whiteEngine, err := ParseEngineSpec(*white)
+	if err != nil {
+		return fmt.Errorf("white: %w", err)
+	}
+
+	ctx, cancel := interruptible(0)
+	defer cancel()
+
+	type gameJSON struct {
+		Start      string `json:"start,omitempty"` // Set-up start position, if not the initial one
+		Transcript string `json:"transcript"`      // Moves played from the start position
+		Black      int    `json:"black"`
+		White      int    `json:"white"`
+	}
+
+	var results []gameJSON
+
+	for i := 0; i < *games && ctx.Err() == nil; i++ {
+		g, err := position.game()
+		if err != nil {
+			return err
+		}
+
+		g.blackAI, g.whiteAI = true, true
+		g.SetEngine(Black, blackEngine)
+		g.SetEngine(White, whiteEngine)
+
+		for !g.IsGameOver() && ctx.Err() == nil {
+			g.Play(g.BestMove(ctx))
+		}
+
+		start := ""
+		if !g.StartsFromInitial() {
+			start = FormatPosition(&g.start, g.startPlayer)
+		}
+
+		blackScore, whiteScore := g.GetScore()
+		results = append(results, gameJSON{start, g.Transcript(), blackScore, whiteScore})
+	}
+
+	return position.output(results, func(w io.Writer) {
+		if len(results) > 0 && results[0].Start != "" {
+			fmt.Fprintf(w, "From %s\n", results[0].Start)
+		}
+
+		for _, r := range results {
+			fmt.Fprintf(w, "%2d-%-2d %s\n", r.Black, r.White, r.Transcript)
+		}
+	})
+}
+
+func runPerft(args []string) error {
+	flags := flag.NewFlagSet("perft", flag.ContinueOnError)
+	position := addPositionFlags(flags)
+	depth := flags.Int("depth", 8, "maximum depth")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	g, err := position.game()
+	if err != nil {
+		return err
+	}
+
+	type perftJSON struct {
+		Depth   int     `json:"depth"`
+		Count   int64   `json:"count"`
+		Seconds float64 `json:"seconds"`
+	}
+
+	var results []perftJSON
+
+	for d := 1; d <= *depth; d++ {
+		start := time.Now()
+		count := g.Perft(d)
+		results = append(results, perftJSON{d, count, time.Since(start).Seconds()})
+	}
+
+	return position.output(results, func(w io.Writer) {
+		for _, r := range results {
+			fmt.Fprintf(w, "perft %2d  %14d  %.3fs\n", r.Depth, r.Count, r.Seconds)
+		}
+	})
+}
+
+// benchPositions are midgame positions searched by the bench command
+var benchPositions = []string{
+	"f5d6c3d3c4f4f6f3e6e7",
+	"d3c5b6d2e3f5e6f4d6c4f3f6e2e1g3g5g4g6",
+	"c4e3f3g3f6e6f5g4g5c5c3c6d3d2c2f4",
+	"f5f4f3f6e6d6c3e3c5c6d3b5f7g3c4e7",
+	"f5d6c3f4f6c4d3f3e3f2e2f1e1g6c5d1",
+	"f5f6e6f4c3e7f3d3e3c6d6c7g4c5d7g5",
+}
+
+func runBench(args []string) error {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	format := flags.String("format", "text", "output format: text or json")
+	depth := flags.Int("depth", 7, "search depth")
+	threads := flags.Int("threads", 1, "number of search threads")
+	hash := flags.Int("hash", DefaultHashSize, "transposition table size in megabytes")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	type benchJSON struct {
+		Position string  `json:"position"`
+		Move     string  `json:"move"`
+		Nodes    int64   `json:"nodes"`
+		Seconds  float64 `json:"seconds"`
+		HashHits float64 `json:"hashHits"` // Share of transposition table probes that found an entry
+		HashFill float64 `json:"hashFill"` // Share of transposition table entries in use
+	}
+
+	var results []benchJSON
+	var totalNodes int64
+	var totalTime time.Duration
+
+	for _, transcript := range benchPositions {
+		g := NewGame()
+		g.book = nil
+		g.threads = *threads
+
+		if err := g.LoadTranscript(transcript); err != nil {
+			return fmt.Errorf("bench position %s: %w", transcript, err)
+		}
+
+		engine := g.Engine(g.current)
+		engine.Depth = *depth
+		engine.MoveTime = time.Hour
+		engine.ExactEmpties, engine.WLDEmpties = 0, 0
+		engine.HashSize = *hash
+
+		var info SearchInfo
+		g.progress = func(i SearchInfo) {
+			info = i
+		}
+
+		start := time.Now()
+		move := g.BestMove(context.Background())
+		elapsed := time.Since(start)
+
+		totalNodes += info.Nodes
+		totalTime += elapsed
+		stats := g.Searcher(g.current).Stats()
+		results = append(results, benchJSON{transcript, SquareName(move.X, move.Y), info.Nodes, elapsed.Seconds(), stats.HitRate(), stats.Fill()})
+	}
+
+	return positionFlags{format: format}.output(results, func(w io.Writer) {
+		for _, r := range results {
+			fmt.Fprintf(w, "%-36s %s  %10d nodes  %.3fs  hash hits %4.1f%%  fill %4.1f%%\n", r.Position, r.Move, r.Nodes, r.Seconds, 100*r.HashHits, 100*r.HashFill)
+		}
+
+		fmt.Fprintf(w, "\nTotal %d nodes in %.3fs, %.0f nodes/s\n", totalNodes, totalTime.Seconds(), float64(totalNodes)/totalTime.Seconds())
+	})
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeControl describes how much thinking time each player gets. Main time is
+// followed by Periods byo-yomi periods of ByoYomi each; a move made within a
+// period keeps it, overrunning it uses it up. Increment is added to the main
+// time after every move. A zero TimeControl means no clock at all.
+type TimeControl struct {
+	Name      string
+	Main      time.Duration
+	Increment time.Duration
+	ByoYomi   time.Duration
+	Periods   int
+}
+
+// TimeControls lists the time controls offered in the UI
+var TimeControls = []TimeControl{
+	{Name: "None"},
+	{Name: "Sudden death 1 min", Main: time.Minute},
+	{Name: "Sudden death 5 min", Main: 5 * time.Minute},
+	{Name: "3 min + 2 s", Main: 3 * time.Minute, Increment: 2 * time.Second},
+	{Name: "10 min + 5 s", Main: 10 * time.Minute, Increment: 5 * time.Second},
+	{Name: "1 min + 3 x 10 s byo-yomi", Main: time.Minute, ByoYomi: 10 * time.Second, Periods: 3},
+	{Name: "5 min + 5 x 30 s byo-yomi", Main: 5 * time.Minute, ByoYomi: 30 * time.Second, Periods: 5},
+}
+
+// Timed reports whether the time control limits thinking time at all
+func (tc TimeControl) Timed() bool {
+	return tc.Main > 0 || (tc.ByoYomi > 0 && tc.Periods > 0)
+}
+
+// ParseTimeControl reads a time control such as "5m" (sudden death), "3m+2s"
+// (increment) or "1m+3x10s" (three byo-yomi periods of ten seconds). "none" or
+// an empty string means no clock.
+func ParseTimeControl(s string) (TimeControl, error) {
+	if s == "" || strings.EqualFold(s, "none") {
+		return TimeControls[0], nil
+	}
+
+	tc := TimeControl{Name: s}
+	mainText, extra, hasExtra := strings.Cut(s, "+")
+
+	main, err := time.ParseDuration(mainText)
+	if err != nil {
+		return tc, fmt.Errorf("time control %q: %w", s, err)
+	}
+
+	tc.Main = main
+
+	if !hasExtra {
+		return tc, nil
+	}
+
+	if periodsText, periodText, isByoYomi := strings.Cut(extra, "x"); isByoYomi {
+		tc.Periods, err = strconv.Atoi(periodsText)
+		if err == nil {
+			tc.ByoYomi, err = time.ParseDuration(periodText)
+		}
+	} else {
+		tc.Increment, err = time.ParseDuration(extra)
+	}
+
+	if err != nil {
+		return tc, fmt.Errorf("time control %q: %w", s, err)
+	}
+
+	return tc, nil
+}
+
+// timeControlNames returns the names of time controls for drop-downs
+func timeControlNames(controls []TimeControl) []string {
+	names := make([]string, len(controls))
+
+	for i, tc := range controls {
+		names[i] = tc.Name
+	}
+
+	return names
+}
+
+// Clock is one player's chess clock
+type Clock struct {
+	control TimeControl
+	main    time.Duration // Main time left
+	periods int           // Byo-yomi periods left
+	used    time.Duration // Time spent on the current move before it was paused
+	running bool
+	started time.Time
+	flagged bool
+}
+
+// NewClock returns a stopped clock with the full time of tc
+func NewClock(tc TimeControl) *Clock {
+	return &Clock{control: tc, main: tc.Main, periods: tc.Periods}
+}
+
+// Start starts the clock at now. Starting a running clock has no effect.
+func (c *Clock) Start(now time.Time) {
+	if c.running || c.flagged {
+		return
+	}
+
+	c.running = true
+	c.started = now
+}
+
+// Stop ends the move at now, charging the time used on it and adding the
+// increment, and reports false if the time ran out. Stopping a stopped clock
+// has no effect.
+func (c *Clock) Stop(now time.Time) bool {
+	if !c.running {
+		return !c.flagged
+	}
+
+	main, periods, period := c.left(now)
+	c.running = false
+	c.used = 0
+
+	if main == 0 && period == 0 {
+		c.main, c.periods, c.flagged = 0, 0, true
+
+		return false
+	}
+
+	c.main = main + c.control.Increment
+	c.periods = periods
+
+	return true
+}
+
+// Pause stops the clock at now without ending the move, for interruptions such
+// as saving or undoing. The time used so far is charged, with the increment,
+// when the move is made. Pause reports false if the time ran out.
+func (c *Clock) Pause(now time.Time) bool {
+	if !c.running {
+		return !c.flagged
+	}
+
+	if c.Expired(now) {
+		return c.Stop(now)
+	}
+
+	c.used += now.Sub(c.started)
+	c.running = false
+
+	return true
+}
+
+// Expired reports whether the time has run out at now
+func (c *Clock) Expired(now time.Time) bool {
+	main, _, period := c.left(now)
+
+	return c.flagged || (main == 0 && period == 0)
+}
+
+// left returns the main time and byo-yomi periods left at now, and the time left
+// in the current period
+func (c *Clock) left(now time.Time) (time.Duration, int, time.Duration) {
+	elapsed := c.used
+	if c.running {
+		elapsed += now.Sub(c.started)
+	}
+
+	if elapsed < c.main {
+		if c.periods == 0 {
+			return c.main - elapsed, 0, 0
+		}
+
+		return c.main - elapsed, c.periods, c.control.ByoYomi
+	}
+
+	if c.control.ByoYomi <= 0 {
+		return 0, 0, 0
+	}
+
+	over := elapsed - c.main
+	used := int(over / c.control.ByoYomi)
+
+	if used >= c.periods {
+		return 0, 0, 0
+	}
+
+	return 0, c.periods - used, c.control.ByoYomi - over%c.control.ByoYomi
+}
+
+// MoveBudget suggests how long to think about the next move at now, given the
+// number of empty squares: an even share of the main time over the moves still
+// to play, plus most of the increment and of a byo-yomi period
+func (c *Clock) MoveBudget(now time.Time, empties int) time.Duration {
+	main, _, period := c.left(now)
+	movesLeft := max((empties+1)/2, 1)
+
+	budget := main/time.Duration(movesLeft) + c.control.Increment*3/4 + period*3/4
+	budget = min(budget, (main+period)*3/4)
+
+	return max(budget, 10*time.Millisecond)
+}
+
+// Format shows the time left at now, such as "4:59.2" or "0:07.5 (2 x 10s)"
+// once the main time is used up
+func (c *Clock) Format(now time.Time) string {
+	main, periods, period := c.left(now)
+
+	switch {
+	case main > 0 && periods > 0:
+		return fmt.Sprintf("%s + %d x %s", formatClock(main), periods, c.control.ByoYomi)
+	case main > 0 || periods == 0:
+		return formatClock(main)
+	default:
+		return fmt.Sprintf("%s (%d x %s)", formatClock(period), periods, c.control.ByoYomi)
+	}
+}
+
+// formatClock formats d as minutes, seconds and tenths
+func formatClock(d time.Duration) string {
+	tenths := int(d / (100 * time.Millisecond))
+
+	return fmt.Sprintf("%d:%02d.%d", tenths/600, tenths/10%60, tenths%10)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockIncrement(t *testing.T) {
+	start := time.Unix(0, 0)
+	c := NewClock(TimeControl{Main: time.Minute, Increment: 2 * time.Second})
+
+	c.Start(start)
+	if !c.Stop(start.Add(10 * time.Second)) {
+		t.Fatal("Stop reported a loss on time")
+	}
+
+	if c.main != 52*time.Second {
+		t.Errorf("main time after a 10s move = %s, want 52s", c.main)
+	}
+
+	// Stopping again charges nothing
+	c.Stop(start.Add(time.Hour))
+
+	if c.main != 52*time.Second {
+		t.Errorf("main time after stopping twice = %s, want 52s", c.main)
+	}
+}
+
+func TestClockTimeout(t *testing.T) {
+	start := time.Unix(0, 0)
+	c := NewClock(TimeControl{Main: time.Minute, Increment: 2 * time.Second})
+
+	c.Start(start)
+
+	if c.Expired(start.Add(59 * time.Second)) {
+		t.Error("clock expired before its time ran out")
+	}
+
+	if !c.Expired(start.Add(time.Minute)) {
+		t.Error("clock not expired when its time ran out")
+	}
+
+	if c.Stop(start.Add(61 * time.Second)) {
+		t.Error("Stop after the time ran out reported no loss")
+	}
+
+	// A flagged clock stays flagged
+	c.Start(start.Add(time.Hour))
+
+	if c.Stop(start.Add(time.Hour)) || !c.Expired(start) {
+		t.Error("flagged clock was restarted")
+	}
+}
+
+func TestClockByoYomi(t *testing.T) {
+	start := time.Unix(0, 0)
+	c := NewClock(TimeControl{Main: 10 * time.Second, ByoYomi: 5 * time.Second, Periods: 2})
+
+	// Main time and half a period: the period is kept
+	c.Start(start)
+	if !c.Stop(start.Add(12 * time.Second)) {
+		t.Fatal("move within the first period lost on time")
+	}
+
+	if c.main != 0 || c.periods != 2 {
+		t.Errorf("after the first move: main %s, %d periods, want 0s and 2", c.main, c.periods)
+	}
+
+	// Overrunning a period uses it up
+	c.Start(start)
+	if !c.Stop(start.Add(7 * time.Second)) {
+		t.Fatal("move within the second period lost on time")
+	}
+
+	if c.periods != 1 {
+		t.Errorf("after overrunning a period: %d periods, want 1", c.periods)
+	}
+
+	c.Start(start)
+	if c.Stop(start.Add(5 * time.Second)) {
+		t.Error("overrunning the last period did not lose on time")
+	}
+}
+
+func TestClockPause(t *testing.T) {
+	start := time.Unix(0, 0)
+	c := NewClock(TimeControl{Main: time.Minute, Increment: 2 * time.Second})
+
+	// Pausing twice during one move grants no increment but charges the time
+	c.Start(start)
+	c.Pause(start.Add(10 * time.Second))
+	c.Start(start.Add(time.Hour))
+	c.Pause(start.Add(time.Hour + 10*time.Second))
+
+	if got := c.Format(start.Add(2 * time.Hour)); got != "0:40.0" {
+		t.Errorf("paused clock shows %s, want 0:40.0", got)
+	}
+
+	c.Start(start.Add(2 * time.Hour))
+	c.Stop(start.Add(2*time.Hour + 10*time.Second))
+
+	if c.main != 32*time.Second {
+		t.Errorf("main time after the move = %s, want 32s", c.main)
+	}
+
+	// The time used before a pause counts towards running out
+	c.Start(start)
+	c.Pause(start.Add(30 * time.Second))
+	c.Start(start)
+
+	if !c.Expired(start.Add(2 * time.Second)) {
+		t.Error("clock not expired after the paused time and the rest of the main time")
+	}
+
+	if c.Pause(start.Add(2 * time.Second)) {
+		t.Error("Pause after the time ran out reported no loss")
+	}
+}
+
+func TestParseTimeControl(t *testing.T) {
+	tests := []struct {
+		text  string
+		want  TimeControl
+		valid bool
+	}{
+		{"none", TimeControls[0], true},
+		{"", TimeControls[0], true},
+		{"5m", TimeControl{Name: "5m", Main: 5 * time.Minute}, true},
+		{"3m+2s", TimeControl{Name: "3m+2s", Main: 3 * time.Minute, Increment: 2 * time.Second}, true},
+		{"1m+3x10s", TimeControl{Name: "1m+3x10s", Main: time.Minute, ByoYomi: 10 * time.Second, Periods: 3}, true},
+		{"5", TimeControl{}, false},
+		{"1m+x10s", TimeControl{}, false},
+		{"1m+2", TimeControl{}, false},
+	}
+
+	for _, test := range tests {
+		tc, err := ParseTimeControl(test.text)
+
+		if (err == nil) != test.valid {
+			t.Errorf("ParseTimeControl(%q) error = %v, want valid %v", test.text, err, test.valid)
+
+			continue
+		}
+
+		if test.valid && tc != test.want {
+			t.Errorf("ParseTimeControl(%q) = %+v, want %+v", test.text, tc, test.want)
+		}
+	}
+}
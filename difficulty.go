@@ -0,0 +1,55 @@
+package main
+
+import "time"
+
+// DifficultyLevel bundles the engine settings behind one of the difficulty names shown in the UI
+type DifficultyLevel struct {
+	Name         string
+	Depth        int
+	MoveTime     time.Duration
+	BookDepth    int
+	ExactEmpties int
+	WLDEmpties   int
+}
+
+// DifficultyLevels lists the difficulty levels from weakest to strongest
+var DifficultyLevels = []DifficultyLevel{
+	{Name: "Easy", Depth: 2, MoveTime: 500 * time.Millisecond, BookDepth: 4, ExactEmpties: 6, WLDEmpties: 8},
+	{Name: "Medium", Depth: 3, MoveTime: time.Second, BookDepth: 6, ExactEmpties: 8, WLDEmpties: 10},
+	{Name: "Hard", Depth: 5, MoveTime: 2 * time.Second, BookDepth: 10, ExactEmpties: 12, WLDEmpties: 14},
+	{Name: "Brutal", Depth: 7, MoveTime: 3 * time.Second, BookDepth: 14, ExactEmpties: 14, WLDEmpties: 16},
+	{Name: "Extreme", Depth: 9, MoveTime: 5 * time.Second, BookDepth: 20, ExactEmpties: 16, WLDEmpties: 18},
+}
+
+// DefaultDifficulty is the difficulty of a new game's AI players
+var DefaultDifficulty = DifficultyLevels[2]
+
+// Config returns the engine configuration of the difficulty level, with the
+// default evaluation weights and no randomness
+func (d DifficultyLevel) Config() EngineConfig {
+	return EngineConfig{
+		Depth:        d.Depth,
+		MoveTime:     d.MoveTime,
+		BookDepth:    d.BookDepth,
+		ExactEmpties: d.ExactEmpties,
+		WLDEmpties:   d.WLDEmpties,
+		HashSize:     DefaultHashSize,
+		Weights:      DefaultWeights,
+	}
+}
+
+// SetDifficulty configures player's AI with the settings of DifficultyLevels[level]
+func (g *Game) SetDifficulty(player, level int) {
+	g.SetEngine(player, DifficultyLevels[level].Config())
+}
+
+// difficultyNames returns the names of DifficultyLevels for drop-downs
+func difficultyNames() []string {
+	names := make([]string, len(DifficultyLevels))
+
+	for i, d := range DifficultyLevels {
+		names[i] = d.Name
+	}
+
+	return names
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// ValidatePosition checks that a set-up position can be played from: the four
+// centre squares are occupied, as in every real game, and the game is not over
+func ValidatePosition(b *Board, current int) error {
+	for _, square := range []string{"d4", "e4", "d5", "e5"} {
+		x, y, _ := ParseSquare(square)
+
+		if b.Get(x, y) == Blank {
+			return fmt.Errorf("the centre square %s must be occupied", square)
+		}
+	}
+
+	g := &Game{board: b, current: current}
+	if g.IsGameOver() {
+		return fmt.Errorf("neither side has a legal move")
+	}
+
+	return nil
+}
+
+// showBoardEditor lets the user set up a position starting from board with
+// current to move. It calls done with the position once it has been validated,
+// asking to analyze rather than play it if analyze is set, or cancel if the
+// user leaves the editor.
+func showBoardEditor(app *tview.Application, board Board, current int, done func(board Board, current int, analyze bool), cancel func()) {
+	boardTable := tview.NewTable()
+	boardTable.SetSelectable(true, true)
+	boardTable.SetBorder(true)
+	boardTable.SetBorders(true)
+	boardTable.SetTitleAlign(tview.AlignLeft)
+	boardTable.SetTitleColor(tcell.ColorYellow)
+	boardTable.SetBorderColor(tcell.ColorYellow)
+
+	helpBox := tview.NewTextView()
+	helpBox.SetBorder(true)
+	helpBox.SetTitle("Board editor")
+	helpBox.SetWrap(true)
+
+	flex := tview.NewFlex().
+		AddItem(boardTable, 0, 1, true).
+		AddItem(helpBox, 60, 1, false)
+
+	status := ""
+	names := map[int]string{Black: "Black", White: "White"}
+
+	update := func() {
+		for y := 0; y < BoardSize; y++ {
+			for x := 0; x < BoardSize; x++ {
+				cell := tview.NewTableCell(getPieceSymbol(board.Get(x, y)))
+				cell.SetAlign(tview.AlignCenter)
+				boardTable.SetCell(y, x, cell)
+			}
+		}
+
+		boardTable.SetTitle(fmt.Sprintf(" Board editor - %s to move ", names[current]))
+
+		black, white := popCount(board.black), popCount(board.white)
+		helpBox.SetText(fmt.Sprintf("Black: %d\nWhite: %d\n%s to move\n\n%s\n\n"+
+			"[Enter] cycle empty, black, white\n[x] black  [o] white  [space] empty\n"+
+			"[t] switch side to move\n[c] clear board  [i] initial position\n\n"+
+			"[p] play  [a] analyze  [Esc] back\n\n%s",
+			black, white, names[current], FormatPosition(&board, current), status))
+	}
+
+	// finish validates the position and hands it over
+	finish := func(analyze bool) {
+		if err := ValidatePosition(&board, current); err != nil {
+			status = "Invalid position: " + err.Error()
+			update()
+
+			return
+		}
+
+		done(board, current, analyze)
+	}
+
+	boardTable.SetSelectedFunc(func(row, column int) {
+		board.Set(column, row, (board.Get(column, row)+1)%3)
+		status = ""
+		update()
+	})
+
+	boardTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEscape {
+			cancel()
+
+			return nil
+		}
+
+		row, column := boardTable.GetSelection()
+
+		switch event.Rune() {
+		case 'x':
+			board.Set(column, row, Black)
+		case 'o':
+			board.Set(column, row, White)
+		case ' ':
+			board.Set(column, row, Blank)
+		case 't':
+			current = Opponent(current)
+		case 'c':
+			board = Board{}
+		case 'i':
+			board, current = *NewBoard(), Black
+		case 'p':
+			finish(false)
+
+			return nil
+		case 'a':
+			finish(true)
+
+			return nil
+		default:
+			return event
+		}
+
+		status = ""
+		update()
+
+		return nil
+	})
+
+	update()
+
+	app.SetRoot(flex, true).SetFocus(boardTable)
+}
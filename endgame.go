@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// Quadrant masks used for parity move ordering
+var quadrants = [4]uint64{
+	0x000000000f0f0f0f,
+	0x00000000f0f0f0f0,
+	0x0f0f0f0f00000000,
+	0xf0f0f0f000000000,
+}
+
+// endgameSearch solves positions to the end of the game. Scores are final disc
+// differences from the point of view of the side to move, with empty squares
+// going to the winner as in tournament scoring.
+type endgameSearch struct {
+	ctx     context.Context
+	nodes   int64
+	aborted bool
+}
+
+// SolveEndgame searches the current position to the end of the game and returns
+// the best move with its exact final disc difference for the side to move. With
+// wldOnly set it only proves win, draw or loss, which is much faster: the score is
+// then positive, zero or negative. It reports false if ctx was cancelled first.
+// A completed solve is published to g.progress.
+func (g *Game) SolveEndgame(ctx context.Context, wldOnly bool) (Move, int, bool) {
+	s := &endgameSearch{ctx: ctx}
+	start := time.Now()
+
+	move, score, completed := s.solveRoot(g, wldOnly)
+
+	if completed {
+		info := SearchInfo{
+			Depth:   g.CountEmptySquares(),
+			Move:    move,
+			Score:   float64(score),
+			Solved:  !wldOnly,
+			Nodes:   s.nodes,
+			Elapsed: time.Since(start),
+			PV:      []Move{move},
+		}
+
+		if wldOnly {
+			// Only the sign is known
+			info.Score *= winScore
+		}
+
+		g.report(info)
+	}
+
+	return move, score, completed
+}
+
+func (s *endgameSearch) solveRoot(g *Game, wldOnly bool) (Move, int, bool) {
+	own, opp := g.board.Discs(g.current), g.board.Discs(Opponent(g.current))
+
+	alpha, beta := -BoardSize*BoardSize-1, BoardSize*BoardSize+1
+	if wldOnly {
+		alpha, beta = -1, 1
+	}
+
+	var bestMove Move
+	bestScore := alpha - 1
+
+	var list [BoardSize * BoardSize]orderedMove
+
+	for _, m := range s.orderMoves(own, opp, legalMoves(own, opp), &list) {
+		score := -s.solve(opp&^m.flips, own|m.flips|1<<uint(m.square), -beta, -alpha, false)
+
+		if s.aborted {
+			return bestMove, bestScore, false
+		}
+
+		if score > bestScore {
+			x, y := squareXY(m.square)
+			bestMove = Move{X: x, Y: y, Flips: m.flips}
+			bestScore = score
+		}
+
+		if score > alpha {
+			alpha = score
+		}
+
+		if alpha >= beta {
+			break
+		}
+	}
+
+	return bestMove, bestScore, true
+}
+
+// solve is a fail-hard alpha-beta search over raw bitboards
+func (s *endgameSearch) solve(own, opp uint64, alpha, beta int, passed bool) int {
+	s.nodes++
+
+	if s.nodes&4095 == 0 && s.ctx.Err() != nil {
+		s.aborted = true
+	}
+
+	if s.aborted {
+		return 0
+	}
+
+	moves := legalMoves(own, opp)
+
+	if moves == 0 {
+		if passed {
+			return finalScore(own, opp)
+		}
+
+		return -s.solve(opp, own, -beta, -alpha, true)
+	}
+
+	var list [BoardSize * BoardSize]orderedMove
+
+	for i, m := range s.orderMoves(own, opp, moves, &list) {
+		childOwn, childOpp := opp&^m.flips, own|m.flips|1<<uint(m.square)
+
+		// Principal variation search: prove the remaining moves worse with a null
+		// window and only re-search the ones that turn out better
+		var score int
+		if i == 0 {
+			score = -s.solve(childOwn, childOpp, -beta, -alpha, false)
+		} else {
+			score = -s.solve(childOwn, childOpp, -alpha-1, -alpha, false)
+			if score > alpha && score < beta {
+				score = -s.solve(childOwn, childOpp, -beta, -alpha, false)
+			}
+		}
+
+		if s.aborted {
+			return 0
+		}
+
+		if score >= beta {
+			return beta
+		}
+
+		if score > alpha {
+			alpha = score
+		}
+	}
+
+	return alpha
+}
+
+type orderedMove struct {
+	square int
+	flips  uint64
+	key    int
+}
+
+// orderMoves sorts moves so that moves into regions with an odd number of
+// empties come first and, away from the very end, moves that leave the
+// opponent the fewest replies (fastest-first) are tried before the rest. The
+// result is stored in list, which lives on the caller's stack.
+func (s *endgameSearch) orderMoves(own, opp, moves uint64, list *[BoardSize * BoardSize]orderedMove) []orderedMove {
+	empty := ^(own | opp)
+	fastestFirst := popCount(empty) > 6
+	n := 0
+
+	for moves != 0 {
+		sq := bitIndex(moves)
+		moves &= moves - 1
+
+		bit := uint64(1) << uint(sq)
+		m := orderedMove{square: sq, flips: flipsFor(own, opp, sq)}
+
+		for _, q := range quadrants {
+			if bit&q != 0 && popCount(empty&q)%2 == 1 {
+				m.key += 8
+			}
+		}
+
+		if fastestFirst {
+			m.key -= 16 * popCount(legalMoves(opp&^m.flips, own|m.flips|bit))
+		}
+
+		// Insertion sort, the lists are short
+		i := n
+		for i > 0 && list[i-1].key < m.key {
+			list[i] = list[i-1]
+			i--
+		}
+
+		list[i] = m
+		n++
+	}
+
+	return list[:n]
+}
+
+// finalScore returns the disc difference of a finished game, giving the empty squares to the winner
+func finalScore(own, opp uint64) int {
+	diff := popCount(own) - popCount(opp)
+	empties := BoardSize*BoardSize - popCount(own|opp)
+
+	switch {
+	case diff > 0:
+		return diff + empties
+	case diff < 0:
+		return diff - empties
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+)
+
+// endgamePosition plays random moves from the initial position until the given
+// number of squares is empty and the side to move has a legal move
+func endgamePosition(seed int64, empties int) *Game {
+	r := rand.New(rand.NewSource(seed))
+
+	for {
+		g := NewGame()
+
+		for g.CountEmptySquares() > empties && !g.IsGameOver() {
+			moves := g.ValidMoves(g.current)
+			if len(moves) == 0 {
+				g.Pass()
+
+				continue
+			}
+
+			g.Play(moves[r.Intn(len(moves))])
+		}
+
+		if len(g.ValidMoves(g.current)) > 0 && g.CountEmptySquares() == empties {
+			return g
+		}
+	}
+}
+
+// minimax scores a position by trying every line to the end, without pruning
+func minimax(own, opp uint64, passed bool) int {
+	moves := legalMoves(own, opp)
+
+	if moves == 0 {
+		if passed {
+			return finalScore(own, opp)
+		}
+
+		return -minimax(opp, own, true)
+	}
+
+	best := -BoardSize*BoardSize - 1
+
+	for ; moves != 0; moves &= moves - 1 {
+		square := bitIndex(moves)
+		flips := flipsFor(own, opp, square)
+		best = max(best, -minimax(opp&^flips, own|flips|1<<uint(square), false))
+	}
+
+	return best
+}
+
+func TestSolveEndgame(t *testing.T) {
+	for seed := int64(1); seed <= 10; seed++ {
+		g := endgamePosition(seed, 9)
+		own, opp := g.board.Discs(g.current), g.board.Discs(Opponent(g.current))
+		want := minimax(own, opp, false)
+
+		move, score, completed := g.SolveEndgame(context.Background(), false)
+		if !completed || score != want {
+			t.Errorf("%s: SolveEndgame = %d, %v, want %d", g.Transcript(), score, completed, want)
+
+			continue
+		}
+
+		// The move must reach the score
+		after := g.SimulateMove(move, true)
+		if got := -minimax(after.board.Discs(after.current), after.board.Discs(g.current), false); got != want {
+			t.Errorf("%s: SolveEndgame move %s scores %d, want %d", g.Transcript(), SquareName(move.X, move.Y), got, want)
+		}
+
+		_, wld, completed := g.SolveEndgame(context.Background(), true)
+		if !completed || sign(wld) != sign(want) {
+			t.Errorf("%s: SolveEndgame(wldOnly) = %d, want the sign of %d", g.Transcript(), wld, want)
+		}
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	default:
+		return 0
+	}
+}
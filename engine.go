@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EvalWeights are the weights of the evaluation components in one game phase
+type EvalWeights struct {
+	Heuristic         float64
+	DiscDifference    float64
+	Mobility          float64
+	Frontier          float64
+	PotentialMobility float64
+	Corner            float64
+	Edge              float64
+}
+
+// DefaultWeights are the evaluation weights for each GamePhase
+var DefaultWeights = [3]EvalWeights{
+	EarlyGame: {Heuristic: 10, DiscDifference: 1, Mobility: 5, Frontier: 5, PotentialMobility: 5, Corner: 25, Edge: 5},
+	MidGame:   {Heuristic: 5, DiscDifference: 1, Mobility: 10, Frontier: 10, PotentialMobility: 10, Corner: 25, Edge: 10},
+	LateGame:  {Heuristic: 1, DiscDifference: 25, Mobility: 1, Frontier: 1, PotentialMobility: 1, Corner: 25, Edge: 15},
+}
+
+// EngineConfig holds the settings of one AI player. The search stops at Depth
+// plies or after MoveTime, whichever comes first.
+type EngineConfig struct {
+	Depth        int            // Maximum search depth
+	MoveTime     time.Duration  // Time budget for each move
+	BookDepth    int            // Number of plies the AI may play from the book, 0 to ignore the book
+	ExactEmpties int            // Solve for the exact score at or below this many empties
+	WLDEmpties   int            // Solve for win/loss/draw at or below this many empties
+	Randomness   float64        // Largest noise added to leaf evaluations, 0 for a deterministic AI
+	HashSize     int            // Transposition table size in megabytes, 0 for DefaultHashSize
+	Threads      int            // Number of parallel search workers, 0 for the game's default
+	Weights      [3]EvalWeights // Evaluation weights for each GamePhase
+}
+
+// maxThreads is the largest number of search workers an engine may ask for
+const maxThreads = 256
+
+// ParseEngineSpec builds an engine configuration from a difficulty name followed
+// by optional comma-separated overrides, e.g. "hard,depth=7,time=1s,random=50".
+// The keys are depth, time, book, exact, wld, random, hash, threads and weights,
+// the latter naming a file in the format read by LoadWeights.
+func ParseEngineSpec(spec string) (EngineConfig, error) {
+	fields := strings.Split(spec, ",")
+
+	var config EngineConfig
+	found := false
+
+	for _, d := range DifficultyLevels {
+		if strings.EqualFold(d.Name, strings.TrimSpace(fields[0])) {
+			config = d.Config()
+			found = true
+		}
+	}
+
+	if !found {
+		return config, fmt.Errorf("unknown difficulty %q, expected one of %s", fields[0], strings.Join(difficultyNames(), ", "))
+	}
+
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			return config, fmt.Errorf("invalid setting %q, expected key=value", field)
+		}
+
+		var err error
+
+		switch key {
+		case "depth":
+			config.Depth, err = parseSetting(value, 1, BoardSize*BoardSize)
+		case "time":
+			if config.MoveTime, err = time.ParseDuration(value); err == nil && config.MoveTime <= 0 {
+				err = fmt.Errorf("must be positive")
+			}
+		case "book":
+			config.BookDepth, err = parseSetting(value, 0, BoardSize*BoardSize-4)
+		case "exact":
+			config.ExactEmpties, err = parseSetting(value, 0, BoardSize*BoardSize)
+		case "wld":
+			config.WLDEmpties, err = parseSetting(value, 0, BoardSize*BoardSize)
+		case "random":
+			config.Randomness, err = strconv.ParseFloat(value, 64)
+			if err == nil && !(config.Randomness >= 0 && config.Randomness <= winScore) {
+				err = fmt.Errorf("must be between 0 and %d", winScore)
+			}
+		case "hash":
+			config.HashSize, err = parseSetting(value, 1, maxHashSize)
+		case "threads":
+			config.Threads, err = parseSetting(value, 1, maxThreads)
+		case "weights":
+			var f *os.File
+
+			if f, err = os.Open(value); err == nil {
+				config.Weights, err = LoadWeights(f)
+				f.Close()
+			}
+		default:
+			err = fmt.Errorf("unknown setting")
+		}
+
+		if err != nil {
+			return config, fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	return config, nil
+}
+
+// parseSetting reads an integer setting that must lie between low and high
+func parseSetting(value string, low, high int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", value)
+	}
+
+	if n < low || n > high {
+		return 0, fmt.Errorf("must be between %d and %d", low, high)
+	}
+
+	return n, nil
+}
+
+// LoadWeights reads evaluation weights, one game phase per line: the phase
+// (early, mid or late) followed by the heuristic, disc difference, mobility,
+// frontier, potential mobility, corner and edge weights. Phases that are not
+// listed keep their DefaultWeights. Blank lines and text after '#' are ignored.
+func LoadWeights(r io.Reader) ([3]EvalWeights, error) {
+	weights := DefaultWeights
+	phases := map[string]GamePhase{"early": EarlyGame, "mid": MidGame, "late": LateGame}
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		phase, ok := phases[strings.ToLower(fields[0])]
+		if !ok || len(fields) != 8 {
+			return weights, fmt.Errorf("line %d: expected a phase and 7 weights", lineNumber)
+		}
+
+		var values [7]float64
+
+		for i, field := range fields[1:] {
+			value, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return weights, fmt.Errorf("line %d: invalid weight %q", lineNumber, field)
+			}
+
+			values[i] = value
+		}
+
+		weights[phase] = EvalWeights{
+			Heuristic:         values[0],
+			DiscDifference:    values[1],
+			Mobility:          values[2],
+			Frontier:          values[3],
+			PotentialMobility: values[4],
+			Corner:            values[5],
+			Edge:              values[6],
+		}
+	}
+
+	return weights, scanner.Err()
+}
+
+// Engine returns the engine configuration of player's AI, which may be changed in place
+func (g *Game) Engine(player int) *EngineConfig {
+	if player == White {
+		return &g.whiteEngine
+	}
+
+	return &g.blackEngine
+}
+
+// SetEngine replaces the engine configuration of player's AI
+func (g *Game) SetEngine(player int, config EngineConfig) {
+	*g.Engine(player) = config
+}
+
+// noise maps a hash to a pseudo-random value in [-1, 1) with the splitmix64
+// finalizer, so that a position always gets the same noise within a search
+func noise(hash uint64) float64 {
+	return float64(mix64(hash)>>11)/(1<<52) - 1
+}
+
+// mix64 is the splitmix64 finalizer, which scrambles the bits of x
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+
+	return x
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseEngineSpec(t *testing.T) {
+	hard := DifficultyLevels[2].Config()
+
+	tests := []struct {
+		spec string
+		want func(c *EngineConfig) // Changes to the Hard configuration, nil for an error
+	}{
+		{"hard", func(c *EngineConfig) {}},
+		{" Hard ", func(c *EngineConfig) {}},
+		{"hard,depth=7,time=1500ms", func(c *EngineConfig) { c.Depth, c.MoveTime = 7, 1500*time.Millisecond }},
+		{"hard, book=0, exact=10, wld=12", func(c *EngineConfig) { c.BookDepth, c.ExactEmpties, c.WLDEmpties = 0, 10, 12 }},
+		{"hard,random=50", func(c *EngineConfig) { c.Randomness = 50 }},
+		{"hard,hash=64", func(c *EngineConfig) { c.HashSize = 64 }},
+		{"hard,threads=4", func(c *EngineConfig) { c.Threads = 4 }},
+		{"impossible", nil},
+		{"hard,depth", nil},
+		{"hard,depth=0", nil},
+		{"hard,depth=-2", nil},
+		{"hard,depth=65", nil},
+		{"hard,depth=x", nil},
+		{"hard,time=0s", nil},
+		{"hard,time=fast", nil},
+		{"hard,book=61", nil},
+		{"hard,exact=-1", nil},
+		{"hard,random=-1", nil},
+		{"hard,random=NaN", nil},
+		{"hard,hash=0", nil},
+		{"hard,threads=0", nil},
+		{"hard,threads=1000", nil},
+		{"hard,speed=1", nil},
+		{"hard,weights=does-not-exist.txt", nil},
+	}
+
+	for _, test := range tests {
+		config, err := ParseEngineSpec(test.spec)
+
+		if test.want == nil {
+			if err == nil {
+				t.Errorf("ParseEngineSpec(%q) succeeded", test.spec)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("ParseEngineSpec(%q) = %v", test.spec, err)
+
+			continue
+		}
+
+		want := hard
+		test.want(&want)
+
+		if config != want {
+			t.Errorf("ParseEngineSpec(%q) = %+v, want %+v", test.spec, config, want)
+		}
+	}
+}
+
+func TestParseEngineSpecWeights(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weights.txt")
+	if err := os.WriteFile(path, []byte("late 1 2 3 4 5 6 7 # tuned\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := ParseEngineSpec("easy,weights=" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := DefaultWeights
+	want[LateGame] = EvalWeights{1, 2, 3, 4, 5, 6, 7}
+
+	if config.Weights != want {
+		t.Errorf("weights = %+v, want %+v", config.Weights, want)
+	}
+}
+
+func TestLoadWeightsErrors(t *testing.T) {
+	for _, text := range []string{"late 1 2 3", "endgame 1 2 3 4 5 6 7", "early 1 2 3 4 5 6 x"} {
+		if _, err := LoadWeights(strings.NewReader(text)); err == nil {
+			t.Errorf("LoadWeights(%q) succeeded", text)
+		}
+	}
+}
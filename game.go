@@ -1,21 +1,41 @@
 package main
 
+import (
+	"math/bits"
+	"runtime"
+)
+
 // Game represents the game state
 type Game struct {
-	board      *Board
-	current    int
-	blackAI    bool
-	whiteAI    bool
-	difficulty int
+	board         *Board
+	current       int
+	blackAI       bool
+	whiteAI       bool
+	blackEngine   EngineConfig // Settings of each side's AI
+	whiteEngine   EngineConfig
+	blackSearcher *Searcher // Tables of each side's AI, shared with copies of the game
+	whiteSearcher *Searcher
+	book          *OpeningBook
+	threads       int   // Number of parallel search workers, 1 for a deterministic search
+	start         Board // Position the history starts from
+	startPlayer   int
+	history       []HistoryEntry
+	redo          []HistoryEntry
+	progress      func(SearchInfo) // Receives search progress; not copied by Copy
 }
 
 // NewGame initializes a new game with the starting position
 func NewGame() *Game {
-	return &Game{
-		board:      NewBoard(),
-		current:    Black,
-		difficulty: 5,
+	g := &Game{
+		blackEngine:   DefaultDifficulty.Config(),
+		whiteEngine:   DefaultDifficulty.Config(),
+		blackSearcher: NewSearcher(),
+		whiteSearcher: NewSearcher(),
+		threads:       runtime.NumCPU(),
 	}
+	g.Reset()
+
+	return g
 }
 
 type GamePhase int
@@ -26,6 +46,17 @@ const (
 	LateGame
 )
 
+func (p GamePhase) String() string {
+	switch p {
+	case EarlyGame:
+		return "Early game"
+	case MidGame:
+		return "Midgame"
+	default:
+		return "Late game"
+	}
+}
+
 var CellHeuristics = [8][8]int{
 	{100, -20, 10, 5, 5, 10, -20, 100},
 	{-20, -50, -2, -2, -2, -2, -50, -20},
@@ -39,14 +70,7 @@ var CellHeuristics = [8][8]int{
 
 // GetGamePhase determines the current phase of the game
 func (g *Game) GetGamePhase() GamePhase {
-	count := 0
-	for x := 0; x < BoardSize; x++ {
-		for y := 0; y < BoardSize; y++ {
-			if g.board[x][y] != Blank {
-				count++
-			}
-		}
-	}
+	count := popCount(g.board.black | g.board.white)
 
 	switch {
 	case count <= 20:
@@ -78,49 +102,44 @@ func Opponent(player int) int {
 
 // ValidMoves returns a list of valid moves for the specified player
 func (g *Game) ValidMoves(player int) []Move {
-	var moves []Move
-	for x := 0; x < BoardSize; x++ {
-		for y := 0; y < BoardSize; y++ {
-			if g.board[x][y] == Blank {
-				if flips := g.Flips(x, y, player); len(flips) > 0 {
-					moves = append(moves, Move{X: x, Y: y, Flips: flips})
-				}
-			}
-		}
-	}
+	own, opp := g.board.Discs(player), g.board.Discs(Opponent(player))
+	legal := legalMoves(own, opp)
 
-	return moves
-}
+	if legal == 0 {
+		return nil
+	}
 
-// Flips returns the list of pieces that would be flipped if a piece is placed at (x, y)
-func (g *Game) Flips(x, y, player int) [][2]int {
-	var totalFlips [][2]int
-	opponent := Opponent(player)
+	moves := make([]Move, 0, popCount(legal))
 
-	for _, dir := range directions {
-		var flips [][2]int
-		nx, ny := x+dir.x, y+dir.y
+	for legal != 0 {
+		sq := bits.TrailingZeros64(legal)
+		legal &= legal - 1
+		x, y := squareXY(sq)
+		moves = append(moves, Move{X: x, Y: y, Flips: flipsFor(own, opp, sq)})
+	}
 
-		for nx >= 0 && nx < BoardSize && ny >= 0 && ny < BoardSize && g.board[nx][ny] == opponent {
-			flips = append(flips, [2]int{nx, ny})
-			nx += dir.x
-			ny += dir.y
-		}
+	return moves
+}
 
-		if nx >= 0 && nx < BoardSize && ny >= 0 && ny < BoardSize && g.board[nx][ny] == player && len(flips) > 0 {
-			totalFlips = append(totalFlips, flips...)
-		}
+// Flips returns the bitboard of pieces that would be flipped if a piece is placed at (x, y)
+func (g *Game) Flips(x, y, player int) uint64 {
+	if g.board.Empty()&squareBit(x, y) == 0 {
+		return 0
 	}
 
-	return totalFlips
+	return flipsFor(g.board.Discs(player), g.board.Discs(Opponent(player)), y*BoardSize+x)
 }
 
 // MakeMove applies the move to the game state
 func (g *Game) MakeMove(move Move, switchTurn bool) {
-	g.board[move.X][move.Y] = g.current
+	placed := squareBit(move.X, move.Y) | move.Flips
 
-	for _, flip := range move.Flips {
-		g.board[flip[0]][flip[1]] = g.current
+	if g.current == Black {
+		g.board.black |= placed
+		g.board.white &^= move.Flips
+	} else {
+		g.board.white |= placed
+		g.board.black &^= move.Flips
 	}
 
 	if switchTurn {
@@ -155,177 +174,91 @@ type ScoreComponents struct {
 	WeightEdge           float64
 }
 
-// EvaluateDetailed evaluates the board and returns the score components
+// Evaluate evaluates the board with the default weights
 func (g *Game) Evaluate(player int) float64 {
 	components := g.EvaluateDetailed(player)
 	return components.TotalScore
 }
 
+// EvaluateDetailed evaluates the board with the default weights and returns the score components
 func (g *Game) EvaluateDetailed(player int) ScoreComponents {
+	return g.EvaluateWith(player, &DefaultWeights)
+}
+
+// EvaluateWith evaluates the board with the weights for the current game phase
+// and returns the score components
+func (g *Game) EvaluateWith(player int, weights *[3]EvalWeights) ScoreComponents {
 	components := ScoreComponents{}
 
-	phase := g.GetGamePhase()
 	opponent := Opponent(player)
 
 	// Adjust weights based on game phase
-	switch phase {
-	case EarlyGame:
-		components.WeightHeuristic = 10.0
-		components.WeightDiscDifference = 1.0
-		components.WeightMobility = 5.0
-		components.WeightFrontier = 5.0
-		components.WeightPotentialMob = 5.0
-		components.WeightCorner = 25.0
-		components.WeightEdge = 5.0
-	case MidGame:
-		components.WeightHeuristic = 5.0
-		components.WeightDiscDifference = 1.0
-		components.WeightMobility = 10.0
-		components.WeightFrontier = 10.0
-		components.WeightPotentialMob = 10.0
-		components.WeightCorner = 25.0
-		components.WeightEdge = 10.0
-	case LateGame:
-		components.WeightHeuristic = 1.0
-		components.WeightDiscDifference = 25.0
-		components.WeightMobility = 1.0
-		components.WeightFrontier = 1.0
-		components.WeightPotentialMob = 1.0
-		components.WeightCorner = 25.0
-		components.WeightEdge = 15.0
-	}
-
-	// Variables to hold counts
+	w := weights[g.GetGamePhase()]
+	components.WeightHeuristic = w.Heuristic
+	components.WeightDiscDifference = w.DiscDifference
+	components.WeightMobility = w.Mobility
+	components.WeightFrontier = w.Frontier
+	components.WeightPotentialMob = w.PotentialMobility
+	components.WeightCorner = w.Corner
+	components.WeightEdge = w.Edge
+
+	own, opp := g.board.Discs(player), g.board.Discs(opponent)
+	empty := g.board.Empty()
+
+	// Positional heuristic over my discs
 	myHeuristic := 0
-	myDiscs, opponentDiscs := 0, 0
-	myFrontierDiscs, opponentFrontierDiscs := 0, 0
-	myPotentialMobility, opponentPotentialMobility := 0, 0
-
-	// Corner positions
-	corners := [][2]int{{0, 0}, {0, 7}, {7, 0}, {7, 7}}
-	myCorners := 0
-	opponentCorners := 0
-
-	// Directions for frontier detection
-	directions := [][2]int{
-		{-1, -1}, {-1, 0}, {-1, 1},
-		{0, -1} /*{0, 0},*/, {0, 1},
-		{1, -1}, {1, 0}, {1, 1},
-	}
 
-	// Evaluate board
-	for x := 0; x < BoardSize; x++ {
-		for y := 0; y < BoardSize; y++ {
-			cell := g.board[x][y]
-
-			if cell == Blank {
-				// Potential mobility
-				for _, dir := range directions {
-					nx, ny := x+dir[0], y+dir[1]
-					if nx >= 0 && nx < BoardSize && ny >= 0 && ny < BoardSize {
-						neighborCell := g.board[nx][ny]
-						if neighborCell == opponent {
-							myPotentialMobility++
-
-							break
-						} else if neighborCell == player {
-							opponentPotentialMobility++
-
-							break
-						}
-					}
-				}
-			}
+	for discs := own; discs != 0; discs &= discs - 1 {
+		x, y := squareXY(bits.TrailingZeros64(discs))
+		value := CellHeuristics[x][y]
 
-			if cell == player || cell == opponent {
-				value := CellHeuristics[x][y]
-
-				// Adjust for X-squares and C-squares
-				if isXSquare(x, y) {
-					cornerX, cornerY := adjacentCorner(x, y)
-					cornerCell := g.board[cornerX][cornerY]
-					if cornerCell != cell {
-						value = -abs(value)
-					}
-				}
-				if isCSquare(x, y) {
-					cornerX, cornerY := adjacentCornerC(x, y)
-					cornerCell := g.board[cornerX][cornerY]
-					if cornerCell != cell {
-						value = -abs(value)
-					}
-				}
-
-				// Count discs and heuristic values
-				if cell == player {
-					myHeuristic += value
-					myDiscs++
-
-					// Check for frontier discs
-					if isFrontierDisc(g.board, x, y) {
-						myFrontierDiscs++
-					}
-				} else if cell == opponent {
-					opponentDiscs++
-
-					// Check for frontier discs
-					if isFrontierDisc(g.board, x, y) {
-						opponentFrontierDiscs++
-					}
-				}
+		// Adjust for X-squares and C-squares
+		if isXSquare(x, y) {
+			cornerX, cornerY := adjacentCorner(x, y)
+			if g.board.Get(cornerX, cornerY) != player {
+				value = -abs(value)
 			}
 		}
+		if isCSquare(x, y) {
+			cornerX, cornerY := adjacentCornerC(x, y)
+			if g.board.Get(cornerX, cornerY) != player {
+				value = -abs(value)
+			}
+		}
+
+		myHeuristic += value
 	}
 
+	myDiscs, opponentDiscs := popCount(own), popCount(opp)
+
+	// Frontier discs touch at least one empty square
+	emptyNeighbours := neighbours(empty)
+	myFrontierDiscs := popCount(own & emptyNeighbours)
+	opponentFrontierDiscs := popCount(opp & emptyNeighbours)
+
+	// Potential mobility counts empty squares next to the other side's discs
+	myPotentialMobility := popCount(empty & neighbours(opp))
+	opponentPotentialMobility := popCount(empty & neighbours(own))
+
 	// Corner ownership
-	for _, corner := range corners {
-		x, y := corner[0], corner[1]
-		cell := g.board[x][y]
-
-		if cell == player {
-			myCorners++
-		} else if cell == opponent {
-			opponentCorners++
-		}
-	}
+	myCorners := popCount(own & cornerMask)
+	opponentCorners := popCount(opp & cornerMask)
 
 	// Edge stability
 	myEdgeStability := 0
 	opponentEdgeStability := 0
-	edges := [][][2]int{
-		// Top edge
-		{{0, 0}, {1, 0}, {2, 0}, {3, 0}, {4, 0}, {5, 0}, {6, 0}, {7, 0}},
-		// Bottom edge
-		{{0, 7}, {1, 7}, {2, 7}, {3, 7}, {4, 7}, {5, 7}, {6, 7}, {7, 7}},
-		// Left edge
-		{{0, 0}, {0, 1}, {0, 2}, {0, 3}, {0, 4}, {0, 5}, {0, 6}, {0, 7}},
-		// Right edge
-		{{7, 0}, {7, 1}, {7, 2}, {7, 3}, {7, 4}, {7, 5}, {7, 6}, {7, 7}},
-	}
 
-	for _, edge := range edges {
-		myEdgeCount := 0
-		opponentEdgeCount := 0
-		for _, pos := range edge {
-			x, y := pos[0], pos[1]
-			cell := g.board[x][y]
-
-			if cell == player {
-				myEdgeCount++
-			} else if cell == opponent {
-				opponentEdgeCount++
-			}
-		}
-		if myEdgeCount == 8 {
-			myEdgeStability += 1
-		} else if opponentEdgeCount == 8 {
-			opponentEdgeStability += 1
+	for _, edge := range []uint64{topEdge, bottomEdge, leftEdge, rightEdge} {
+		if own&edge == edge {
+			myEdgeStability++
+		} else if opp&edge == edge {
+			opponentEdgeStability++
 		}
 	}
 
 	// Mobility
-	myMobility := len(g.ValidMoves(player))
-	opponentMobility := len(g.ValidMoves(opponent))
+	myMobility := popCount(legalMoves(own, opp))
+	opponentMobility := popCount(legalMoves(opp, own))
 
 	// Heuristic
 	components.Heuristic = float64(myHeuristic)
@@ -441,19 +374,6 @@ func adjacentCornerC(x, y int) (int, int) {
 	}
 }
 
-// isFrontierDisc checks if a disc is a frontier disc
-func isFrontierDisc(board *Board, x, y int) bool {
-	for _, dir := range directions {
-		nx, ny := x+dir.x, y+dir.y
-		if nx >= 0 && nx < BoardSize && ny >= 0 && ny < BoardSize {
-			if board[nx][ny] == Blank {
-				return true
-			}
-		}
-	}
-	return false
-}
-
 // Helper function to get absolute value
 func abs(a int) int {
 	if a < 0 {
@@ -466,31 +386,51 @@ func abs(a int) int {
 // Copy creates a deep copy of the game state
 func (g *Game) Copy() *Game {
 	return &Game{
-		board:      g.board.Copy(),
-		current:    g.current,
-		difficulty: g.difficulty,
+		board:         g.board.Copy(),
+		current:       g.current,
+		blackEngine:   g.blackEngine,
+		whiteEngine:   g.whiteEngine,
+		blackSearcher: g.blackSearcher,
+		whiteSearcher: g.whiteSearcher,
+		book:          g.book,
+		threads:       g.threads,
+		start:         g.start,
+		startPlayer:   g.startPlayer,
 	}
 }
 
 // Reset resets the game state to the initial state
 func (g *Game) Reset() {
-	g.board = NewBoard()
-	g.current = Black
+	g.SetPosition(NewBoard(), Black)
+}
+
+// SetPosition starts the game over from an arbitrary position with the given side to move
+func (g *Game) SetPosition(board *Board, current int) {
+	g.board = board.Copy()
+	g.current = current
+	g.start = *board
+	g.startPlayer = current
+	g.history = nil
+	g.redo = nil
+}
+
+// replaceWith takes over the position and move history of other, keeping the
+// players and AI settings of g
+func (g *Game) replaceWith(other *Game) {
+	g.board = other.board
+	g.current = other.current
+	g.start = other.start
+	g.startPlayer = other.startPlayer
+	g.history = other.history
+	g.redo = nil
+}
+
+// plyCount returns the number of discs placed since the initial position
+func (g *Game) plyCount() int {
+	return popCount(g.board.black|g.board.white) - 4
 }
 
 // GetScore returns the score of the game
 func (g *Game) GetScore() (int, int) {
-	blackCount, whiteCount := 0, 0
-
-	for x := 0; x < BoardSize; x++ {
-		for y := 0; y < BoardSize; y++ {
-			if g.board[x][y] == Black {
-				blackCount++
-			} else if g.board[x][y] == White {
-				whiteCount++
-			}
-		}
-	}
-
-	return blackCount, whiteCount
+	return popCount(g.board.black), popCount(g.board.white)
 }
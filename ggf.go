@@ -0,0 +1,428 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// GGFRecord is a game record in the Generic Game Format used by GGS, NBoard and
+// most Othello databases, e.g.
+//
+//	(;GM[Othello]PC[GGS/os]PB[alice]PW[bob]RB[2012.35]RW[1865.10]TI[05:00//02:00]
+//	TY[8]RE[+4.00]BO[8 -------- ... ---*O--- ... *]B[f5//0.01]W[d6/-2.00/1.20];)
+type GGFRecord struct {
+	Place       string // PC
+	Date        string // DT
+	BlackPlayer string // PB
+	WhitePlayer string // PW
+	BlackRating string // RB
+	WhiteRating string // RW
+	TimeControl string // TI, shared by both players
+	BlackTime   string // TB
+	WhiteTime   string // TW
+	Type        string // TY
+	Result      string // RE, disc difference from Black's point of view
+	Start       Board  // BO
+	StartPlayer int
+	Moves       []GGFMove
+	Extra       []GGFProperty // Properties not listed above, kept for round-tripping
+}
+
+// GGFMove is a single B[...] or W[...] entry of a GGF record
+type GGFMove struct {
+	Player int
+	Move   Move
+	Eval   string
+	Time   string
+}
+
+// GGFProperty is a raw KEY[value] pair
+type GGFProperty struct {
+	Key   string
+	Value string
+}
+
+// NewGGFRecord builds a GGF record from the position and move history of g
+func NewGGFRecord(g *Game) *GGFRecord {
+	r := &GGFRecord{
+		Place:       "reversi",
+		Date:        time.Now().UTC().Format("2006-01-02 15:04:05 GMT"),
+		BlackPlayer: g.playerLabel(Black),
+		WhitePlayer: g.playerLabel(White),
+		Type:        "8",
+		Result:      "?",
+		Start:       g.start,
+		StartPlayer: g.startPlayer,
+	}
+
+	for _, entry := range g.history {
+		r.Moves = append(r.Moves, GGFMove{Player: entry.Player, Move: entry.Move})
+	}
+
+	if g.IsGameOver() {
+		blackScore, whiteScore := g.GetScore()
+		r.Result = formatGGFResult(blackScore - whiteScore)
+	}
+
+	return r
+}
+
+// playerLabel names a player for game records
+func (g *Game) playerLabel(player int) string {
+	if g.IsAI(player) {
+		return "reversi-ai"
+	}
+
+	return "human"
+}
+
+func formatGGFResult(diff int) string {
+	if diff == 0 {
+		return "0.00"
+	}
+
+	return fmt.Sprintf("%+d.00", diff)
+}
+
+// ReadGGF reads every game record in r, such as a GGF archive with one game per line
+func ReadGGF(r io.Reader) ([]*GGFRecord, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []*GGFRecord
+	text := string(data)
+
+	for {
+		start := strings.Index(text, "(;")
+		if start < 0 {
+			break
+		}
+
+		end := strings.Index(text[start:], ";)")
+		if end < 0 {
+			return nil, fmt.Errorf("game %d: unterminated record", len(records)+1)
+		}
+
+		record, err := ParseGGF(text[start : start+end+2])
+		if err != nil {
+			return nil, fmt.Errorf("game %d: %w", len(records)+1, err)
+		}
+
+		records = append(records, record)
+		text = text[start+end+2:]
+	}
+
+	return records, nil
+}
+
+// ParseGGF parses a single "(; ... ;)" game record
+func ParseGGF(s string) (*GGFRecord, error) {
+	s = strings.TrimSpace(s)
+
+	if !strings.HasPrefix(s, "(;") || !strings.HasSuffix(s, ";)") {
+		return nil, fmt.Errorf("record must be enclosed in (; and ;)")
+	}
+
+	r := &GGFRecord{}
+	body := s[2 : len(s)-2]
+	hasBoard := false
+
+	for {
+		body = strings.TrimSpace(body)
+		if body == "" {
+			break
+		}
+
+		start := strings.IndexByte(body, '[')
+		if start <= 0 {
+			return nil, fmt.Errorf("malformed property near %q", truncate(body, 16))
+		}
+
+		end := strings.IndexByte(body[start:], ']')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated value for %s", body[:start])
+		}
+
+		key := strings.TrimSpace(body[:start])
+		value := body[start+1 : start+end]
+		body = body[start+end+1:]
+
+		switch key {
+		case "GM":
+			if !strings.EqualFold(value, "Othello") {
+				return nil, fmt.Errorf("unsupported game %q", value)
+			}
+		case "PC":
+			r.Place = value
+		case "DT":
+			r.Date = value
+		case "PB":
+			r.BlackPlayer = value
+		case "PW":
+			r.WhitePlayer = value
+		case "RB":
+			r.BlackRating = value
+		case "RW":
+			r.WhiteRating = value
+		case "TI":
+			r.TimeControl = value
+		case "TB":
+			r.BlackTime = value
+		case "TW":
+			r.WhiteTime = value
+		case "TY":
+			r.Type = value
+		case "RE":
+			r.Result = value
+		case "BO":
+			board, current, err := parseGGFBoard(value)
+			if err != nil {
+				return nil, err
+			}
+
+			r.Start, r.StartPlayer = *board, current
+			hasBoard = true
+		case "B", "W":
+			move, err := parseGGFMove(key, value)
+			if err != nil {
+				return nil, fmt.Errorf("move %d: %w", len(r.Moves)+1, err)
+			}
+
+			r.Moves = append(r.Moves, move)
+		default:
+			r.Extra = append(r.Extra, GGFProperty{Key: key, Value: value})
+		}
+	}
+
+	if !hasBoard {
+		return nil, fmt.Errorf("missing BO property")
+	}
+
+	return r, nil
+}
+
+// parseGGFBoard parses a BO value: the board size, 64 squares of '*' (Black),
+// 'O' (White) or '-' (empty), optionally split into rows, and the side to move
+func parseGGFBoard(value string) (*Board, int, error) {
+	fields := strings.Fields(value)
+
+	if len(fields) < 2 || fields[0] != "8" {
+		return nil, 0, fmt.Errorf("unsupported board %q", value)
+	}
+
+	squares := strings.Join(fields[1:], "")
+	if len(squares) != BoardSize*BoardSize+1 {
+		return nil, 0, fmt.Errorf("board %q does not have 64 squares and a side to move", value)
+	}
+
+	board := &Board{}
+
+	for sq := 0; sq < BoardSize*BoardSize; sq++ {
+		x, y := squareXY(sq)
+
+		switch squares[sq] {
+		case '*', 'x', 'X':
+			board.Set(x, y, Black)
+		case 'O', 'o':
+			board.Set(x, y, White)
+		case '-', '.':
+		default:
+			return nil, 0, fmt.Errorf("invalid square %q in board", squares[sq])
+		}
+	}
+
+	switch squares[len(squares)-1] {
+	case '*', 'x', 'X':
+		return board, Black, nil
+	case 'O', 'o':
+		return board, White, nil
+	}
+
+	return nil, 0, fmt.Errorf("invalid side to move %q", squares[len(squares)-1])
+}
+
+// formatGGFBoard is the inverse of parseGGFBoard
+func formatGGFBoard(board *Board, current int) string {
+	var sb strings.Builder
+	sb.WriteString("8")
+
+	for y := 0; y < BoardSize; y++ {
+		sb.WriteByte(' ')
+
+		for x := 0; x < BoardSize; x++ {
+			switch board.Get(x, y) {
+			case Black:
+				sb.WriteByte('*')
+			case White:
+				sb.WriteByte('O')
+			default:
+				sb.WriteByte('-')
+			}
+		}
+	}
+
+	if current == Black {
+		sb.WriteString(" *")
+	} else {
+		sb.WriteString(" O")
+	}
+
+	return sb.String()
+}
+
+// parseGGFMove parses a move value of the form "square/eval/time"
+func parseGGFMove(key, value string) (GGFMove, error) {
+	move := GGFMove{Player: Black}
+	if key == "W" {
+		move.Player = White
+	}
+
+	parts := strings.SplitN(value, "/", 3)
+	if len(parts) > 1 {
+		move.Eval = parts[1]
+	}
+	if len(parts) > 2 {
+		move.Time = parts[2]
+	}
+
+	square := strings.TrimSpace(parts[0])
+
+	if strings.EqualFold(square, "PA") || strings.EqualFold(square, "pass") {
+		move.Move.Pass = true
+
+		return move, nil
+	}
+
+	x, y, err := ParseSquare(square)
+	if err != nil {
+		return move, err
+	}
+
+	move.Move.X, move.Move.Y = x, y
+
+	return move, nil
+}
+
+// String writes the record in GGF
+func (r *GGFRecord) String() string {
+	var sb strings.Builder
+
+	writeProp := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(&sb, "%s[%s]", key, value)
+		}
+	}
+
+	sb.WriteString("(;")
+	writeProp("GM", "Othello")
+	writeProp("PC", r.Place)
+	writeProp("DT", r.Date)
+	writeProp("PB", r.BlackPlayer)
+	writeProp("PW", r.WhitePlayer)
+	writeProp("RB", r.BlackRating)
+	writeProp("RW", r.WhiteRating)
+	writeProp("TI", r.TimeControl)
+	writeProp("TB", r.BlackTime)
+	writeProp("TW", r.WhiteTime)
+	writeProp("TY", r.Type)
+	writeProp("RE", r.Result)
+
+	for _, prop := range r.Extra {
+		writeProp(prop.Key, prop.Value)
+	}
+
+	writeProp("BO", formatGGFBoard(&r.Start, r.StartPlayer))
+
+	for _, move := range r.Moves {
+		key := "B"
+		if move.Player == White {
+			key = "W"
+		}
+
+		value := "PA"
+		if !move.Move.Pass {
+			value = strings.ToUpper(SquareName(move.Move.X, move.Move.Y))
+		}
+
+		if move.Eval != "" || move.Time != "" {
+			value += "/" + move.Eval
+		}
+		if move.Time != "" {
+			value += "/" + move.Time
+		}
+
+		writeProp(key, value)
+	}
+
+	sb.WriteString(";)")
+
+	return sb.String()
+}
+
+// Game replays the record into a new game, rejecting illegal moves. A missing
+// pass is inserted when a player has no legal move.
+func (r *GGFRecord) Game() (*Game, error) {
+	g := NewGame()
+	g.SetPosition(&r.Start, r.StartPlayer)
+
+	for i, move := range r.Moves {
+		if move.Player != g.current {
+			if len(g.ValidMoves(g.current)) > 0 || g.IsGameOver() {
+				return nil, fmt.Errorf("move %d: %s moved out of turn", i+1, g.PlayerName(move.Player))
+			}
+
+			g.Pass()
+		}
+
+		if move.Move.Pass {
+			if len(g.ValidMoves(g.current)) > 0 {
+				return nil, fmt.Errorf("move %d: %s passed with legal moves available", i+1, g.PlayerName(move.Player))
+			}
+
+			g.Pass()
+
+			continue
+		}
+
+		if err := g.playSquare(move.Move.X, move.Move.Y); err != nil {
+			return nil, fmt.Errorf("move %d: %w", i+1, err)
+		}
+	}
+
+	return g, nil
+}
+
+// LoadGGF replaces the game with the first record in text. The game is left
+// untouched if the record cannot be parsed or contains an illegal move.
+func (g *Game) LoadGGF(text string) error {
+	records, err := ReadGGF(strings.NewReader(text))
+	if err != nil {
+		return err
+	}
+
+	if len(records) == 0 {
+		return fmt.Errorf("no GGF game record found")
+	}
+
+	replay, err := records[0].Game()
+	if err != nil {
+		return err
+	}
+
+	g.replaceWith(replay)
+
+	return nil
+}
+
+// truncate shortens s to at most n bytes for use in error messages
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+
+	return s
+}
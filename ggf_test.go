@@ -0,0 +1,122 @@
+package main
+
+import "testing"
+
+func TestGGFRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		board      string // BO value of a set-up start position, empty for the initial one
+		transcript string // Moves played from the initial position
+		moves      int    // Moves played by the first legal move rule afterwards
+	}{
+		{"initial position", "", "", 0},
+		{"transcript", "", "f5d6c3d3c4", 4},
+		{"forced pass", "", passTranscript, 0},
+		{"set-up position", "8 -XXXXX----XXXXX-OOXOOXO-OOXOXO-OOOOXOOOOOOXOOOXO-XXXOO-O--OOOO-- X", "", 3},
+	}
+
+	for _, test := range tests {
+		g := NewGame()
+		if err := g.LoadTranscript(test.transcript); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if test.board != "" {
+			board, current, err := parseGGFBoard(test.board)
+			if err != nil {
+				t.Fatalf("%s: %v", test.name, err)
+			}
+
+			g.SetPosition(board, current)
+		}
+
+		for i := 0; i < test.moves && !g.IsGameOver(); i++ {
+			if moves := g.ValidMoves(g.current); len(moves) > 0 {
+				g.Play(moves[0])
+			} else {
+				g.Pass()
+			}
+		}
+
+		text := NewGGFRecord(g).String()
+
+		loaded := NewGame()
+		if err := loaded.LoadGGF(text); err != nil {
+			t.Fatalf("%s: LoadGGF(%q) = %v", test.name, text, err)
+		}
+
+		if *loaded.board != *g.board || loaded.current != g.current || loaded.Transcript() != g.Transcript() {
+			t.Errorf("%s: reloaded game is %+v after %q, want %+v after %q", test.name, *loaded.board, loaded.Transcript(), *g.board, g.Transcript())
+		}
+
+		if loaded.start != g.start || loaded.startPlayer != g.startPlayer {
+			t.Errorf("%s: reloaded game has another start position", test.name)
+		}
+
+		if loaded.StartsFromInitial() != (test.board == "") {
+			t.Errorf("%s: StartsFromInitial() = %v", test.name, loaded.StartsFromInitial())
+		}
+	}
+}
+
+func TestParseGGF(t *testing.T) {
+	initial := "BO[8 -------- -------- -------- ---O*--- ---*O--- -------- -------- -------- *]"
+
+	tests := []struct {
+		name       string
+		record     string
+		transcript string
+		valid      bool
+	}{
+		{"moves", "(;GM[Othello]PC[GGS/os]PB[alice]PW[bob]TY[8]RE[+4.00]" + initial + "B[f5//0.01]W[d6/-2.00/1.20];)", "f5d6", true},
+		{"unknown properties kept", "(;GM[Othello]XX[y]" + initial + "B[F5];)", "f5", true},
+		{"illegal move", "(;GM[Othello]" + initial + "B[a1];)", "", false},
+		{"missing board", "(;GM[Othello]B[f5];)", "", false},
+		{"other game", "(;GM[Chess]" + initial + ";)", "", false},
+		{"unterminated", "(;GM[Othello]" + initial + "B[f5;)", "", false},
+		{"not a record", "f5d6", "", false},
+	}
+
+	for _, test := range tests {
+		g := NewGame()
+		err := g.LoadGGF(test.record)
+
+		if (err == nil) != test.valid {
+			t.Errorf("%s: LoadGGF error = %v, want valid %v", test.name, err, test.valid)
+
+			continue
+		}
+
+		if test.valid && g.Transcript() != test.transcript {
+			t.Errorf("%s: Transcript() = %q, want %q", test.name, g.Transcript(), test.transcript)
+		}
+	}
+}
+
+func TestParseGGFMove(t *testing.T) {
+	tests := []struct {
+		key, value string
+		want       GGFMove
+		valid      bool
+	}{
+		{"B", "f5", GGFMove{Player: Black, Move: Move{X: 5, Y: 4}}, true},
+		{"W", "D6/-2.00/1.20", GGFMove{Player: White, Move: Move{X: 3, Y: 5}, Eval: "-2.00", Time: "1.20"}, true},
+		{"B", "PA", GGFMove{Player: Black, Move: Move{Pass: true}}, true},
+		{"W", "pass//3", GGFMove{Player: White, Move: Move{Pass: true}, Time: "3"}, true},
+		{"B", "z9", GGFMove{}, false},
+	}
+
+	for _, test := range tests {
+		got, err := parseGGFMove(test.key, test.value)
+
+		if (err == nil) != test.valid {
+			t.Errorf("parseGGFMove(%q, %q) error = %v, want valid %v", test.key, test.value, err, test.valid)
+
+			continue
+		}
+
+		if test.valid && got != test.want {
+			t.Errorf("parseGGFMove(%q, %q) = %+v, want %+v", test.key, test.value, got, test.want)
+		}
+	}
+}
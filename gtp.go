@@ -0,0 +1,392 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errGTPQuit ends the GTP session after the quit command has been answered
+var errGTPQuit = errors.New("quit")
+
+// gtpCommands lists the supported commands for list_commands and known_command
+var gtpCommands = []string{
+	"protocol_version", "name", "version", "known_command", "list_commands", "quit",
+	"boardsize", "clear_board", "komi", "play", "genmove", "undo", "showboard",
+	"time_settings", "time_left", "final_score",
+}
+
+// gtpEngine plays through the Go Text Protocol, using Othello coordinates a1 to
+// h8 as vertices. Time is given with Canadian byo-yomi: after the main time, a
+// number of stones (moves) must be played within each period.
+type gtpEngine struct {
+	game   *Game
+	engine EngineConfig
+
+	timed      bool
+	byoYomi    time.Duration // Length of a byo-yomi period, 0 for sudden death
+	byoStones  int           // Moves per byo-yomi period
+	timeLeft   map[int]time.Duration
+	stonesLeft map[int]int // 0 while in main time
+}
+
+func runGTP(args []string) error {
+	flags := flag.NewFlagSet("gtp", flag.ContinueOnError)
+	engineSpec := flags.String("engine", "hard", "engine: a difficulty name with optional overrides")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	engine, err := ParseEngineSpec(*engineSpec)
+	if err != nil {
+		return err
+	}
+
+	e := &gtpEngine{game: NewGame(), engine: engine}
+	e.game.book = DefaultBook()
+
+	return e.serve(os.Stdin, os.Stdout)
+}
+
+// serve answers commands until quit or the end of the input
+func (e *gtpEngine) serve(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		// An optional numeric id is echoed in the response
+		id := ""
+		if _, err := strconv.Atoi(fields[0]); err == nil {
+			id, fields = fields[0], fields[1:]
+		}
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		result, err := e.handle(strings.ToLower(fields[0]), fields[1:])
+
+		if err != nil && err != errGTPQuit {
+			fmt.Fprintf(out, "?%s %v\n\n", id, err)
+
+			continue
+		}
+
+		fmt.Fprintf(out, "=%s %s\n\n", id, result)
+
+		if err == errGTPQuit {
+			return nil
+		}
+	}
+
+	return scanner.Err()
+}
+
+// handle runs one command and returns its response
+func (e *gtpEngine) handle(command string, args []string) (string, error) {
+	switch command {
+	case "protocol_version":
+		return "2", nil
+	case "name":
+		return "reversi", nil
+	case "version":
+		return "1.0", nil
+	case "known_command":
+		if len(args) != 1 {
+			return "", fmt.Errorf("syntax error")
+		}
+
+		for _, c := range gtpCommands {
+			if c == args[0] {
+				return "true", nil
+			}
+		}
+
+		return "false", nil
+	case "list_commands":
+		return strings.Join(gtpCommands, "\n"), nil
+	case "quit":
+		return "", errGTPQuit
+	case "boardsize":
+		if len(args) != 1 || args[0] != strconv.Itoa(BoardSize) {
+			return "", fmt.Errorf("unacceptable size")
+		}
+
+		return "", nil
+	case "clear_board":
+		e.game.Reset()
+
+		return "", nil
+	case "komi":
+		// Othello has no komi
+		return "", nil
+	case "play":
+		return "", e.play(args)
+	case "genmove":
+		return e.genmove(args)
+	case "undo":
+		if !e.game.Undo() {
+			return "", fmt.Errorf("cannot undo")
+		}
+
+		return "", nil
+	case "showboard":
+		return "\n" + e.showBoard(), nil
+	case "time_settings":
+		return "", e.timeSettings(args)
+	case "time_left":
+		return "", e.timeLeftCommand(args)
+	case "final_score":
+		blackScore, whiteScore := e.game.GetScore()
+
+		switch {
+		case blackScore > whiteScore:
+			return fmt.Sprintf("B+%d", blackScore-whiteScore), nil
+		case whiteScore > blackScore:
+			return fmt.Sprintf("W+%d", whiteScore-blackScore), nil
+		default:
+			return "0", nil
+		}
+	default:
+		return "", fmt.Errorf("unknown command")
+	}
+}
+
+// parseGTPColor reads a color argument
+func parseGTPColor(s string) (int, error) {
+	switch strings.ToLower(s) {
+	case "b", "black":
+		return Black, nil
+	case "w", "white":
+		return White, nil
+	default:
+		return 0, fmt.Errorf("invalid color %q", s)
+	}
+}
+
+// checkTurn reports an error unless color is to move, or may move after a pass
+// of the side to move because it has no legal move
+func (e *gtpEngine) checkTurn(color int) error {
+	if e.game.current != color && len(e.game.ValidMoves(e.game.current)) > 0 {
+		return fmt.Errorf("illegal move: it is %s's turn", strings.ToLower(e.game.PlayerName(e.game.current)))
+	}
+
+	return nil
+}
+
+// toMove makes color the side to move, passing for the opponent if it is stuck
+func (e *gtpEngine) toMove(color int) error {
+	if err := e.checkTurn(color); err != nil {
+		return err
+	}
+
+	if e.game.current != color {
+		e.game.Pass()
+	}
+
+	return nil
+}
+
+// play checks the move completely before changing the game, so that a rejected
+// move leaves it as it was
+func (e *gtpEngine) play(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("syntax error")
+	}
+
+	color, err := parseGTPColor(args[0])
+	if err != nil {
+		return err
+	}
+
+	if err := e.checkTurn(color); err != nil {
+		return err
+	}
+
+	move := Move{Pass: true}
+
+	if strings.EqualFold(args[1], "pass") {
+		if len(e.game.ValidMoves(color)) > 0 {
+			return fmt.Errorf("illegal move: pass with legal moves available")
+		}
+	} else {
+		x, y, err := ParseSquare(args[1])
+		if err != nil {
+			return err
+		}
+
+		move = Move{X: x, Y: y, Flips: e.game.Flips(x, y, color)}
+		if move.Flips == 0 {
+			return fmt.Errorf("illegal move: %s is not a legal move for %s", SquareName(x, y), strings.ToLower(e.game.PlayerName(color)))
+		}
+	}
+
+	if err := e.toMove(color); err != nil {
+		return err
+	}
+
+	e.game.Play(move)
+
+	return nil
+}
+
+func (e *gtpEngine) genmove(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("syntax error")
+	}
+
+	color, err := parseGTPColor(args[0])
+	if err != nil {
+		return "", err
+	}
+
+	if err := e.toMove(color); err != nil {
+		return "", err
+	}
+
+	position := e.game.Copy()
+	engine := e.engine
+
+	if e.timed {
+		engine.MoveTime = e.moveBudget(color)
+		engine.Depth = BoardSize * BoardSize
+	}
+
+	position.SetEngine(color, engine)
+
+	move := position.BestMove(context.Background())
+	e.game.Play(move)
+
+	if move.Pass {
+		return "pass", nil
+	}
+
+	return SquareName(move.X, move.Y), nil
+}
+
+// moveBudget divides color's remaining time over the moves it must make with it
+func (e *gtpEngine) moveBudget(color int) time.Duration {
+	left, stones := e.timeLeft[color], e.stonesLeft[color]
+
+	moves := max((e.game.CountEmptySquares()+1)/2, 1)
+	if stones > 0 {
+		moves = min(moves, stones)
+	}
+
+	return max(left*3/4/time.Duration(moves), 10*time.Millisecond)
+}
+
+// timeSettings handles time_settings main_time byo_yomi_time byo_yomi_stones,
+// all times in seconds. Zero stones with a byo-yomi time, or with no time at
+// all, means no limit: the engine's own depth and time settings apply.
+func (e *gtpEngine) timeSettings(args []string) error {
+	if len(args) != 3 {
+		return fmt.Errorf("syntax error")
+	}
+
+	numbers := make([]int, 3)
+
+	for i, arg := range args {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 0 {
+			return fmt.Errorf("syntax error")
+		}
+
+		numbers[i] = n
+	}
+
+	main := time.Duration(numbers[0]) * time.Second
+	e.byoYomi = time.Duration(numbers[1]) * time.Second
+	e.byoStones = numbers[2]
+	e.timed = e.byoStones > 0 || (main > 0 && e.byoYomi == 0)
+
+	e.timeLeft = map[int]time.Duration{Black: main, White: main}
+	e.stonesLeft = map[int]int{Black: 0, White: 0}
+
+	if main == 0 {
+		// Straight into byo-yomi
+		e.timeLeft = map[int]time.Duration{Black: e.byoYomi, White: e.byoYomi}
+		e.stonesLeft = map[int]int{Black: e.byoStones, White: e.byoStones}
+	}
+
+	return nil
+}
+
+// timeLeftCommand handles time_left color time stones
+func (e *gtpEngine) timeLeftCommand(args []string) error {
+	if len(args) != 3 {
+		return fmt.Errorf("syntax error")
+	}
+
+	color, err := parseGTPColor(args[0])
+	if err != nil {
+		return err
+	}
+
+	seconds, err := strconv.Atoi(args[1])
+	if err != nil {
+		return fmt.Errorf("syntax error")
+	}
+
+	stones, err := strconv.Atoi(args[2])
+	if err != nil {
+		return fmt.Errorf("syntax error")
+	}
+
+	if e.timeLeft == nil {
+		e.timeLeft = map[int]time.Duration{}
+		e.stonesLeft = map[int]int{}
+	}
+
+	e.timed = true
+	e.timeLeft[color] = time.Duration(seconds) * time.Second
+	e.stonesLeft[color] = stones
+
+	return nil
+}
+
+// showBoard draws the board with coordinates, X for Black and O for White
+func (e *gtpEngine) showBoard() string {
+	var sb strings.Builder
+
+	sb.WriteString("  A B C D E F G H\n")
+
+	for y := 0; y < BoardSize; y++ {
+		fmt.Fprintf(&sb, "%d", y+1)
+
+		for x := 0; x < BoardSize; x++ {
+			switch e.game.board.Get(x, y) {
+			case Black:
+				sb.WriteString(" X")
+			case White:
+				sb.WriteString(" O")
+			default:
+				sb.WriteString(" .")
+			}
+		}
+
+		sb.WriteByte('\n')
+	}
+
+	blackScore, whiteScore := e.game.GetScore()
+	fmt.Fprintf(&sb, "Black (X) %d, White (O) %d, %s to move", blackScore, whiteScore, e.game.PlayerName(e.game.current))
+
+	return sb.String()
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// gtpSession runs commands through e and returns the responses, one per command
+func gtpSession(t *testing.T, e *gtpEngine, commands ...string) []string {
+	t.Helper()
+
+	var out bytes.Buffer
+
+	if err := e.serve(strings.NewReader(strings.Join(commands, "\n")), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	return strings.Split(strings.TrimSuffix(out.String(), "\n\n"), "\n\n")
+}
+
+func newTestGTP() *gtpEngine {
+	return &gtpEngine{game: NewGame(), engine: DifficultyLevels[0].Config()}
+}
+
+func TestGTPCommands(t *testing.T) {
+	e := newTestGTP()
+
+	responses := gtpSession(t, e,
+		"1 protocol_version",
+		"boardsize 8",
+		"boardsize 19",
+		"known_command genmove # a comment",
+		"known_command fly",
+		"2 play b f5",
+		"play b d6",
+		"play w a1",
+		"play w pass",
+		"play w d6",
+		"3 final_score",
+		"undo",
+		"frobnicate",
+		"quit",
+		"name",
+	)
+
+	want := []string{
+		"=1 2",
+		"= ",
+		"? unacceptable size",
+		"= true",
+		"= false",
+		"=2 ",
+		"? illegal move: it is white's turn",
+		"? illegal move: a1 is not a legal move for white",
+		"? illegal move: pass with legal moves available",
+		"= ",
+		"=3 0",
+		"= ",
+		"? unknown command",
+		"= ",
+	}
+
+	if strings.Join(responses, "|") != strings.Join(want, "|") {
+		t.Errorf("responses\n%q\nwant\n%q", responses, want)
+	}
+
+	if got := e.game.Transcript(); got != "f5" {
+		t.Errorf("transcript %q, want f5", got)
+	}
+}
+
+func TestGTPGenmove(t *testing.T) {
+	e := newTestGTP()
+
+	responses := gtpSession(t, e, "play b f5", "genmove w", "genmove w")
+
+	if !strings.HasPrefix(responses[1], "= ") {
+		t.Fatalf("genmove w = %q", responses[1])
+	}
+
+	if responses[2] != "? illegal move: it is black's turn" {
+		t.Errorf("second genmove w = %q, want an error", responses[2])
+	}
+
+	if history := e.game.History(); len(history) != 2 || SquareName(history[1].Move.X, history[1].Move.Y) != strings.TrimPrefix(responses[1], "= ") {
+		t.Errorf("history %+v does not end with the generated move %s", history, responses[1])
+	}
+}
+
+func TestGTPPlayAfterPass(t *testing.T) {
+	// White has no legal move, so Black may play at once
+	board, current, err := ParsePosition("OOOOOOOOOOOOOOOOOOOOOOOOOOOXOOOOOOOOOOOOOOOOOOOOOOOOOOOOOOOO---- O;")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e := newTestGTP()
+	e.game.SetPosition(board, current)
+
+	if responses := gtpSession(t, e, "play b h8"); responses[0] != "= " {
+		t.Fatalf("play b h8 = %q", responses[0])
+	}
+
+	if history := e.game.History(); len(history) != 2 || !history[0].Move.Pass || history[1].Player != Black {
+		t.Errorf("history %+v, want a White pass and a Black move", history)
+	}
+}
+
+func TestGTPTimeSettings(t *testing.T) {
+	tests := []struct {
+		settings string
+		timed    bool
+		left     time.Duration
+		stones   int
+	}{
+		{"0 0 0", false, 0, 0},
+		{"0 30 0", false, 30 * time.Second, 0},
+		{"60 0 0", true, time.Minute, 0},
+		{"60 30 5", true, time.Minute, 0},
+		{"0 30 5", true, 30 * time.Second, 5},
+	}
+
+	for _, test := range tests {
+		e := newTestGTP()
+
+		if responses := gtpSession(t, e, "time_settings "+test.settings); responses[0] != "= " {
+			t.Errorf("time_settings %s = %q", test.settings, responses[0])
+
+			continue
+		}
+
+		if e.timed != test.timed || e.timeLeft[Black] != test.left || e.stonesLeft[White] != test.stones {
+			t.Errorf("time_settings %s: timed %v, %v and %d stones left, want %v, %v and %d", test.settings, e.timed, e.timeLeft[Black], e.stonesLeft[White], test.timed, test.left, test.stones)
+		}
+	}
+
+	e := newTestGTP()
+
+	responses := gtpSession(t, e, "time_settings 1 2", "time_settings -1 0 0", "time_left b 10 3")
+	if responses[0] != "? syntax error" || responses[1] != "? syntax error" || responses[2] != "= " {
+		t.Errorf("responses %q, want two syntax errors and a success", responses)
+	}
+
+	if !e.timed || e.timeLeft[Black] != 10*time.Second || e.stonesLeft[Black] != 3 {
+		t.Errorf("time_left b 10 3: timed %v, %v and %d stones left", e.timed, e.timeLeft[Black], e.stonesLeft[Black])
+	}
+}
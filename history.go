@@ -0,0 +1,121 @@
+package main
+
+// HistoryEntry records a single ply so that it can be taken back and replayed
+type HistoryEntry struct {
+	Player int
+	Move   Move
+}
+
+// Play applies a move for the current player, or a pass if move.Pass is set,
+// and records it in the history. Playing a new move discards any moves that
+// were undone.
+func (g *Game) Play(move Move) {
+	g.history = append(g.history, HistoryEntry{Player: g.current, Move: move})
+	g.redo = g.redo[:0]
+
+	if move.Pass {
+		g.SwitchTurn()
+	} else {
+		g.MakeMove(move, true)
+	}
+}
+
+// Pass records that the current player has no legal move and hands the turn over
+func (g *Game) Pass() {
+	g.Play(Move{Pass: true})
+}
+
+// Undo takes back the last ply, returning false if there is nothing to undo
+func (g *Game) Undo() bool {
+	if len(g.history) == 0 {
+		return false
+	}
+
+	entry := g.history[len(g.history)-1]
+	g.history = g.history[:len(g.history)-1]
+
+	if !entry.Move.Pass {
+		placed := squareBit(entry.Move.X, entry.Move.Y)
+
+		if entry.Player == Black {
+			g.board.black &^= placed | entry.Move.Flips
+			g.board.white |= entry.Move.Flips
+		} else {
+			g.board.white &^= placed | entry.Move.Flips
+			g.board.black |= entry.Move.Flips
+		}
+	}
+
+	g.current = entry.Player
+	g.redo = append(g.redo, entry)
+
+	return true
+}
+
+// Redo replays the last undone ply, returning false if there is nothing to redo
+func (g *Game) Redo() bool {
+	if len(g.redo) == 0 {
+		return false
+	}
+
+	entry := g.redo[len(g.redo)-1]
+	g.redo = g.redo[:len(g.redo)-1]
+	g.current = entry.Player
+
+	if entry.Move.Pass {
+		g.SwitchTurn()
+	} else {
+		g.MakeMove(entry.Move, true)
+	}
+
+	g.history = append(g.history, entry)
+
+	return true
+}
+
+// History returns the plies played so far, including passes
+func (g *Game) History() []HistoryEntry {
+	return g.history
+}
+
+// EvaluationHistory replays the game and returns Black's evaluation of the
+// start position and of the position after every ply
+func (g *Game) EvaluationHistory() []float64 {
+	replay := g.Copy()
+	replay.board = replay.start.Copy()
+	replay.current = replay.startPlayer
+
+	evals := make([]float64, 0, len(g.history)+1)
+	evals = append(evals, replay.Evaluate(Black))
+
+	for _, entry := range g.history {
+		replay.current = entry.Player
+
+		if entry.Move.Pass {
+			replay.SwitchTurn()
+		} else {
+			replay.MakeMove(entry.Move, true)
+		}
+
+		evals = append(evals, replay.Evaluate(Black))
+	}
+
+	return evals
+}
+
+// IsAI reports whether the given player is controlled by the AI
+func (g *Game) IsAI(player int) bool {
+	return (player == Black && g.blackAI) || (player == White && g.whiteAI)
+}
+
+// hasHumanMove reports whether the history contains a move made by a human
+// player, not counting passes
+func (g *Game) hasHumanMove() bool {
+	for _, entry := range g.history {
+		if !g.IsAI(entry.Player) && !entry.Move.Pass {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,126 @@
+package main
+
+import "testing"
+
+// passTranscript is a game in which Black has to pass before White's last move
+const passTranscript = "d3c3f5d2d1e1b2c1a3"
+
+// playLine plays the moves of a transcript such as "f5d6", passing for a player
+// that has no legal move
+func playLine(t *testing.T, g *Game, line string) {
+	t.Helper()
+
+	for i := 0; i+1 < len(line); i += 2 {
+		if len(g.ValidMoves(g.current)) == 0 {
+			g.Pass()
+		}
+
+		x, y := int(line[i]-'a'), int(line[i+1]-'1')
+
+		flips := g.Flips(x, y, g.current)
+		if flips == 0 {
+			t.Fatalf("%s is not a legal move after %q", line[i:i+2], line[:i])
+		}
+
+		g.Play(Move{X: x, Y: y, Flips: flips})
+	}
+}
+
+func TestUndoRedo(t *testing.T) {
+	tests := []struct {
+		name       string
+		transcript string
+	}{
+		{"no moves", ""},
+		{"opening", "f5d6c3d3c4"},
+		{"forced pass", passTranscript},
+	}
+
+	for _, test := range tests {
+		g := NewGame()
+		playLine(t, g, test.transcript)
+
+		// Positions after each ply, passes included
+		type position struct {
+			board   Board
+			current int
+		}
+
+		replay := NewGame()
+		positions := []position{{*replay.board, replay.current}}
+
+		for _, entry := range g.History() {
+			if entry.Move.Pass {
+				replay.Pass()
+			} else {
+				replay.Play(entry.Move)
+			}
+
+			positions = append(positions, position{*replay.board, replay.current})
+		}
+
+		for i := len(positions) - 1; i > 0; i-- {
+			if got := (position{*g.board, g.current}); got != positions[i] {
+				t.Fatalf("%s: after undoing to ply %d position = %+v, want %+v", test.name, i, got, positions[i])
+			}
+
+			if !g.Undo() {
+				t.Fatalf("%s: Undo at ply %d = false", test.name, i)
+			}
+		}
+
+		if (position{*g.board, g.current}) != positions[0] || g.Undo() {
+			t.Fatalf("%s: undoing every ply did not stop at the initial position", test.name)
+		}
+
+		for i := 1; i < len(positions); i++ {
+			if !g.Redo() {
+				t.Fatalf("%s: Redo to ply %d = false", test.name, i)
+			}
+
+			if got := (position{*g.board, g.current}); got != positions[i] {
+				t.Fatalf("%s: after redoing to ply %d position = %+v, want %+v", test.name, i, got, positions[i])
+			}
+		}
+
+		if g.Redo() {
+			t.Errorf("%s: Redo after replaying every ply = true", test.name)
+		}
+	}
+}
+
+func TestPlayDiscardsRedo(t *testing.T) {
+	g := NewGame()
+	playLine(t, g, "f5d6c3")
+
+	g.Undo()
+	g.Undo()
+	g.Play(g.ValidMoves(g.current)[0])
+
+	if g.Redo() {
+		t.Error("Redo after playing a new move = true")
+	}
+
+	if got := len(g.History()); got != 2 {
+		t.Errorf("history has %d plies, want 2", got)
+	}
+}
+
+func TestHasHumanMove(t *testing.T) {
+	g := NewGame()
+	g.whiteAI = true
+
+	// Black passes and White's AI moves: there is no human move to take back
+	g.Pass()
+	g.Play(g.ValidMoves(White)[0])
+
+	if g.hasHumanMove() {
+		t.Error("hasHumanMove() = true after a human pass")
+	}
+
+	g.Play(g.ValidMoves(Black)[0])
+
+	if !g.hasHumanMove() {
+		t.Error("hasHumanMove() = false after a human move")
+	}
+}
@@ -1,6 +1,13 @@
 package main
 
+import (
+	"fmt"
+	"os"
+)
+
 func main() {
-	game := NewGame()
-	game.StartUI()
+	if err := runCommand(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "reversi:", err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// learnPlies is how much of a game the learn command adds to the opening book
+const learnPlies = 24
+
+// nboardEngine drives the engine through the NBoard protocol: one command per
+// line on the input, answers and status messages on the output. Commands are
+// handled in order, so pong is only sent once every earlier command is done.
+type nboardEngine struct {
+	game      *Game
+	engine    EngineConfig
+	learnFile string // Learned games are appended here if set
+
+	mu  sync.Mutex // Serializes writes from the search progress callback
+	out io.Writer
+}
+
+func runNBoard(args []string) error {
+	flags := flag.NewFlagSet("nboard", flag.ContinueOnError)
+	engineSpec := flags.String("engine", "hard", "engine: a difficulty name with optional overrides; set depth from NBoard overrides the depth")
+	learnFile := flags.String("learn", "", "append games learned through the learn command to this book file")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	engine, err := ParseEngineSpec(*engineSpec)
+	if err != nil {
+		return err
+	}
+
+	e := &nboardEngine{game: NewGame(), engine: engine, learnFile: *learnFile, out: os.Stdout}
+	e.game.book = DefaultBook()
+
+	return e.serve(os.Stdin)
+}
+
+// serve handles commands until the input ends
+func (e *nboardEngine) serve(in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		if err := e.handle(strings.TrimSpace(scanner.Text())); err != nil {
+			// Report the problem without dropping the connection
+			e.send("status error: %v", err)
+			fmt.Fprintln(os.Stderr, "nboard:", err)
+		}
+	}
+
+	return scanner.Err()
+}
+
+func (e *nboardEngine) send(format string, args ...any) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	fmt.Fprintf(e.out, format+"\n", args...)
+}
+
+// handle runs one command
+func (e *nboardEngine) handle(line string) error {
+	command, rest, _ := strings.Cut(line, " ")
+	rest = strings.TrimSpace(rest)
+
+	switch command {
+	case "":
+		return nil
+	case "nboard":
+		e.send("set myname reversi")
+	case "set":
+		return e.set(rest)
+	case "move":
+		move, err := parseGGFMove(map[int]string{Black: "B", White: "W"}[e.game.current], rest)
+		if err != nil {
+			return err
+		}
+
+		if move.Move.Pass {
+			if len(e.game.ValidMoves(e.game.current)) > 0 {
+				return fmt.Errorf("pass with legal moves available")
+			}
+
+			e.game.Pass()
+
+			return nil
+		}
+
+		return e.game.playSquare(move.Move.X, move.Move.Y)
+	case "go":
+		e.goMove()
+	case "hint":
+		n, err := strconv.Atoi(rest)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid hint count %q", rest)
+		}
+
+		e.hint(n)
+	case "learn":
+		if err := e.learn(); err != nil {
+			return err
+		}
+
+		e.send("learned")
+	case "ping":
+		e.send("pong %s", rest)
+	case "analyze":
+		// Optional in the protocol; answering nothing is allowed
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+
+	return nil
+}
+
+// set handles set depth, set game and set contempt
+func (e *nboardEngine) set(args string) error {
+	key, value, _ := strings.Cut(args, " ")
+
+	switch key {
+	case "depth":
+		depth, err := parseSetting(strings.TrimSpace(value), 1, BoardSize*BoardSize)
+		if err != nil {
+			return fmt.Errorf("depth: %w", err)
+		}
+
+		e.engine.Depth = depth
+	case "game":
+		g := e.game.Copy()
+		if err := g.LoadGGF(value); err != nil {
+			return err
+		}
+
+		e.game = g
+	case "contempt":
+		// Draws are scored as zero
+	default:
+		return fmt.Errorf("unknown setting %q", key)
+	}
+
+	return nil
+}
+
+// search prepares a copy of the game to be searched with progress reports
+func (e *nboardEngine) search() *Game {
+	g := e.game.Copy()
+	g.SetEngine(g.current, e.engine)
+
+	g.progress = func(info SearchInfo) {
+		e.send("status depth %d %s %+.2f", info.Depth, strings.ToUpper(SquareName(info.Move.X, info.Move.Y)), nboardEval(info.Score, info.Solved))
+	}
+
+	return g
+}
+
+// goMove searches the position and answers with the move to play
+func (e *nboardEngine) goMove() {
+	g := e.search()
+
+	var last SearchInfo
+	report := g.progress
+	g.progress = func(info SearchInfo) {
+		last = info
+		report(info)
+	}
+
+	start := time.Now()
+	move := g.BestMove(context.Background())
+	elapsed := time.Since(start)
+
+	e.send("nodestats %d %.3f", last.Nodes, elapsed.Seconds())
+	e.send("status")
+
+	square := "PA"
+	if !move.Pass {
+		square = strings.ToUpper(SquareName(move.X, move.Y))
+	}
+
+	if last.Depth > 0 && last.Move == move {
+		e.send("=== %s/%.2f/%.3f", square, nboardEval(last.Score, last.Solved), elapsed.Seconds())
+	} else {
+		e.send("=== %s", square)
+	}
+}
+
+// hint sends search lines for the n best moves
+func (e *nboardEngine) hint(n int) {
+	g := e.search()
+
+	e.send("status Analyzing")
+
+	scores := g.AnalyzeMoves(context.Background())
+
+	for i, score := range scores {
+		if i == n {
+			break
+		}
+
+		depth := strconv.Itoa(score.Depth)
+		if score.Solved {
+			depth += "@100%"
+		}
+
+		e.send("search %s %.2f 0 %s", strings.ToUpper(SquareName(score.Move.X, score.Move.Y)), nboardEval(score.Score, score.Solved), depth)
+	}
+
+	e.send("status")
+}
+
+// learn adds the opening of the current game to the book
+func (e *nboardEngine) learn() error {
+	if !e.game.StartsFromInitial() {
+		// Only games from the initial position fit in the book
+		return nil
+	}
+
+	line := e.game.Transcript()
+	if len(line) > 2*learnPlies {
+		line = line[:2*learnPlies]
+	}
+
+	if line == "" {
+		return nil
+	}
+
+	if err := e.game.book.addLine(line, 1); err != nil {
+		return err
+	}
+
+	if e.learnFile == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(e.learnFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(f, "%s 1\n", line); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
+
+// nboardEval converts a search score to the disc-based evaluation NBoard shows
+func nboardEval(score float64, solved bool) float64 {
+	switch {
+	case solved:
+		return score
+	case score >= winScore/2:
+		return math.Max(score-winScore, 1)
+	case score <= -winScore/2:
+		return math.Min(score+winScore, -1)
+	default:
+		return score / 100
+	}
+}
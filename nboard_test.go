@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// newTestNBoard returns an NBoard engine with a shallow search and an empty
+// book, writing to out
+func newTestNBoard(out *bytes.Buffer) *nboardEngine {
+	book, _ := LoadBook(strings.NewReader(""))
+
+	e := &nboardEngine{game: NewGame(), engine: DifficultyLevels[0].Config(), out: out}
+	e.game.book = book
+
+	return e
+}
+
+func TestNBoardCommands(t *testing.T) {
+	opening := NewGame()
+	if err := opening.LoadTranscript("f5d6"); err != nil {
+		t.Fatal(err)
+	}
+
+	input := strings.Join([]string{
+		"nboard 2",
+		"set depth 3",
+		"set game " + NewGGFRecord(opening).String(),
+		"move C3",
+		"move a1",
+		"move PA",
+		"set depth 0",
+		"frobnicate",
+		"ping 7",
+	}, "\n")
+
+	var out bytes.Buffer
+	e := newTestNBoard(&out)
+
+	if err := e.serve(strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+
+	if lines[0] != "set myname reversi" || lines[len(lines)-1] != "pong 7" {
+		t.Errorf("output %q, want set myname first and pong 7 last", lines)
+	}
+
+	// The illegal move, the pass, the depth and the unknown command are refused
+	if errors := strings.Count(out.String(), "status error:"); errors != 4 {
+		t.Errorf("%d errors reported in %q, want 4", errors, out.String())
+	}
+
+	if got := e.game.Transcript(); got != "f5d6c3" {
+		t.Errorf("transcript %q, want f5d6c3", got)
+	}
+
+	if e.engine.Depth != 3 {
+		t.Errorf("depth %d, want 3", e.engine.Depth)
+	}
+}
+
+func TestNBoardPass(t *testing.T) {
+	// White has no legal move
+	board, current, err := ParsePosition("OOOOOOOOOOOOOOOOOOOOOOOOOOOXOOOOOOOOOOOOOOOOOOOOOOOOOOOOOOOO---- O;")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	e := newTestNBoard(&out)
+	e.game.SetPosition(board, current)
+
+	if err := e.handle("move PA"); err != nil {
+		t.Fatalf("move PA = %v", err)
+	}
+
+	if history := e.game.History(); e.game.current != Black || len(history) != 1 || !history[0].Move.Pass {
+		t.Errorf("history %+v after move PA, want a White pass", history)
+	}
+
+	if err := e.handle("go"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out.String(), "=== H8") {
+		t.Errorf("go answered %q, want the only legal move H8", out.String())
+	}
+}
+
+func TestNBoardGo(t *testing.T) {
+	var out bytes.Buffer
+	e := newTestNBoard(&out)
+
+	if err := e.serve(strings.NewReader("move f5\ngo\nhint 2\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	answer := regexp.MustCompile(`(?m)^=== ([A-H][1-8])`).FindStringSubmatch(out.String())
+	if answer == nil {
+		t.Fatalf("no move in %q", out.String())
+	}
+
+	x, y, _ := ParseSquare(answer[1])
+	if e.game.Flips(x, y, White) == 0 {
+		t.Errorf("go answered %s, which is not legal for White after f5", answer[1])
+	}
+
+	// go and hint only search; the game is unchanged
+	if got := e.game.Transcript(); got != "f5" {
+		t.Errorf("transcript %q after go, want f5", got)
+	}
+
+	if searches := strings.Count(out.String(), "\nsearch "); searches != 2 {
+		t.Errorf("%d search lines for hint 2 in %q, want 2", searches, out.String())
+	}
+}
+
+func TestNBoardLearn(t *testing.T) {
+	var out bytes.Buffer
+	e := newTestNBoard(&out)
+	e.learnFile = filepath.Join(t.TempDir(), "learned.txt")
+
+	if err := e.serve(strings.NewReader("move f5\nmove d6\nlearn\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out.String(), "learned") {
+		t.Errorf("output %q, want learned", out.String())
+	}
+
+	if moves := e.game.book.Moves(NewGame()); len(moves) != 1 || SquareName(moves[0].Move.X, moves[0].Move.Y) != "f5" {
+		t.Errorf("book moves %+v from the initial position, want f5", moves)
+	}
+
+	data, err := os.ReadFile(e.learnFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(data) != "f5d6 1\n" {
+		t.Errorf("learn file %q, want %q", data, "f5d6 1\n")
+	}
+}
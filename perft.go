@@ -0,0 +1,36 @@
+package main
+
+// Perft counts the move sequences of the given number of plies from the current
+// position. A pass counts as a ply and a finished game counts as one sequence
+// however many plies remain.
+func (g *Game) Perft(depth int) int64 {
+	own, opp := g.board.Discs(g.current), g.board.Discs(Opponent(g.current))
+
+	return perft(own, opp, depth, false)
+}
+
+func perft(own, opp uint64, depth int, passed bool) int64 {
+	if depth == 0 {
+		return 1
+	}
+
+	moves := legalMoves(own, opp)
+
+	if moves == 0 {
+		if passed {
+			return 1
+		}
+
+		return perft(opp, own, depth-1, true)
+	}
+
+	var count int64
+
+	for ; moves != 0; moves &= moves - 1 {
+		square := bitIndex(moves)
+		flips := flipsFor(own, opp, square)
+		count += perft(opp&^flips, own|flips|1<<uint(square), depth-1, false)
+	}
+
+	return count
+}
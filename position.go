@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FormatPosition writes a board in the OBF style used by common Othello tools:
+// the 64 squares from a1, b1, ... to h8 as X (black), O (white) or - (empty),
+// then a space, the side to move and a semicolon, e.g.
+// "---------------------------OX------XO--------------------------- X;"
+func FormatPosition(b *Board, current int) string {
+	var sb strings.Builder
+
+	for y := 0; y < BoardSize; y++ {
+		for x := 0; x < BoardSize; x++ {
+			switch b.Get(x, y) {
+			case Black:
+				sb.WriteByte('X')
+			case White:
+				sb.WriteByte('O')
+			default:
+				sb.WriteByte('-')
+			}
+		}
+	}
+
+	if current == White {
+		sb.WriteString(" O;")
+	} else {
+		sb.WriteString(" X;")
+	}
+
+	return sb.String()
+}
+
+// ParsePosition reads a board written by FormatPosition. Black discs may also
+// be given as '*' or 'B', white discs as 'W' and empty squares as '.', in either
+// case; whitespace is ignored and anything after the semicolon, such as OBF move
+// scores, is skipped.
+func ParsePosition(s string) (*Board, int, error) {
+	s, _, _ = strings.Cut(s, ";")
+	s = strings.Join(strings.Fields(s), "")
+
+	if len(s) != BoardSize*BoardSize+1 {
+		return nil, 0, fmt.Errorf("position needs %d squares and the side to move, got %d characters", BoardSize*BoardSize, len(s))
+	}
+
+	board := &Board{}
+
+	for i, c := range strings.ToUpper(s[:BoardSize*BoardSize]) {
+		x, y := i%BoardSize, i/BoardSize
+
+		switch c {
+		case 'X', '*', 'B':
+			board.Set(x, y, Black)
+		case 'O', 'W':
+			board.Set(x, y, White)
+		case '-', '.':
+		default:
+			return nil, 0, fmt.Errorf("invalid square %q at %s", c, SquareName(x, y))
+		}
+	}
+
+	switch strings.ToUpper(s[BoardSize*BoardSize:]) {
+	case "X", "*", "B":
+		return board, Black, nil
+	case "O", "W":
+		return board, White, nil
+	default:
+		return nil, 0, fmt.Errorf("invalid side to move %q", s[BoardSize*BoardSize:])
+	}
+}
+
+// Position returns the current position in the format of FormatPosition
+func (g *Game) Position() string {
+	return FormatPosition(g.board, g.current)
+}
+
+// LoadPosition starts the game over from a position in the format read by
+// ParsePosition, passing at once if the side to move has no legal move
+func (g *Game) LoadPosition(s string) error {
+	board, current, err := ParsePosition(s)
+	if err != nil {
+		return err
+	}
+
+	g.SetPosition(board, current)
+	g.passIfStuck()
+
+	return nil
+}
+
+// LoadStart sets up a starting position given either as a board string or as a
+// move transcript from the initial position
+func (g *Game) LoadStart(s string) error {
+	if isPositionString(s) {
+		return g.LoadPosition(s)
+	}
+
+	return g.LoadTranscript(s)
+}
+
+// isPositionString tells board strings from transcripts, which never contain
+// empty square marks and always have an even length
+func isPositionString(s string) bool {
+	return strings.ContainsAny(s, "-.;*") || len(strings.Join(strings.Fields(s), "")) == BoardSize*BoardSize+1
+}
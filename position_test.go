@@ -0,0 +1,83 @@
+package main
+
+import "testing"
+
+// initialPosition is the initial position in the format of FormatPosition
+const initialPosition = "---------------------------OX------XO--------------------------- X;"
+
+func TestParsePosition(t *testing.T) {
+	tests := []struct {
+		name     string
+		position string
+		want     string // FormatPosition of the result
+		valid    bool
+	}{
+		{"initial", initialPosition, initialPosition, true},
+		{"OBF annotations", initialPosition + " f5:+0; d3:+0;", initialPosition, true},
+		{"other marks", "...........................WB......BW........................... *", initialPosition, true},
+		{"rows", "-------- -------- -------- ---OX--- ---XO--- -------- -------- -------- X", initialPosition, true},
+		{"white to move", "---------------------------OX------XO--------------------------- O", "---------------------------OX------XO--------------------------- O;", true},
+		{"short", "---------------------------OX------XO-------------------------- X", "", false},
+		{"invalid square", "---------------------------OX------XQ--------------------------- X", "", false},
+		{"invalid side", "---------------------------OX------XO--------------------------- -", "", false},
+		{"empty", "", "", false},
+	}
+
+	for _, test := range tests {
+		board, current, err := ParsePosition(test.position)
+
+		if (err == nil) != test.valid {
+			t.Errorf("%s: ParsePosition error = %v, want valid %v", test.name, err, test.valid)
+
+			continue
+		}
+
+		if test.valid {
+			if got := FormatPosition(board, current); got != test.want {
+				t.Errorf("%s: FormatPosition = %s, want %s", test.name, got, test.want)
+			}
+		}
+	}
+}
+
+func TestLoadPositionPassesIfStuck(t *testing.T) {
+	g := NewGame()
+
+	// White has no legal move, so Black moves at once
+	if err := g.LoadPosition("OOOOOOOOOOOOOOOOOOOOOOOOOOOXOOOOOOOOOOOOOOOOOOOOOOOOOOOOOOOO---- O;"); err != nil {
+		t.Fatal(err)
+	}
+
+	if g.current != Black || len(g.History()) != 1 || !g.History()[0].Move.Pass {
+		t.Errorf("LoadPosition: %s to move after %d plies, want Black after a White pass", g.PlayerName(g.current), len(g.History()))
+	}
+
+	if g.StartsFromInitial() {
+		t.Error("StartsFromInitial() = true for a set-up position")
+	}
+}
+
+func TestLoadStart(t *testing.T) {
+	tests := []struct {
+		start string
+		want  string // Position after loading
+	}{
+		{"", initialPosition},
+		{"f5", "---------------------------OX------XXX-------------------------- O;"},
+		{initialPosition, initialPosition},
+	}
+
+	for _, test := range tests {
+		g := NewGame()
+
+		if err := g.LoadStart(test.start); err != nil {
+			t.Errorf("LoadStart(%q) = %v", test.start, err)
+
+			continue
+		}
+
+		if got := g.Position(); got != test.want {
+			t.Errorf("LoadStart(%q): position = %s, want %s", test.start, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// progressInterval is the number of nodes the main search worker visits between
+// two progress reports within an iteration
+const progressInterval = 1 << 16
+
+// SearchInfo describes the progress of a search, as published to Game.progress
+type SearchInfo struct {
+	Depth   int     // Deepest completed iteration, or the number of empties when solved
+	Move    Move    // Best move so far
+	Score   float64 // Score of Move from the point of view of the side to move
+	Solved  bool    // Score is the exact final disc difference
+	Nodes   int64   // Nodes visited by all search workers
+	Elapsed time.Duration
+	PV      []Move // Principal variation, starting with Move
+}
+
+// NodesPerSecond returns the search speed over the whole search
+func (info SearchInfo) NodesPerSecond() float64 {
+	if info.Elapsed <= 0 {
+		return 0
+	}
+
+	return float64(info.Nodes) / info.Elapsed.Seconds()
+}
+
+// PVString formats the principal variation in transcript notation, with "--"
+// for passes
+func (info SearchInfo) PVString() string {
+	squares := make([]string, 0, len(info.PV))
+
+	for _, move := range info.PV {
+		if move.Pass {
+			squares = append(squares, "--")
+		} else {
+			squares = append(squares, SquareName(move.X, move.Y))
+		}
+	}
+
+	return strings.Join(squares, " ")
+}
+
+// report publishes progress if anyone is listening
+func (g *Game) report(info SearchInfo) {
+	if g.progress != nil {
+		g.progress(info)
+	}
+}
+
+// principalVariation follows the best moves stored in the transposition table
+// from the current position, starting with first, for at most maxLength plies
+func (g *Game) principalVariation(tt *transTable, first Move, maxLength int) []Move {
+	pv := []Move{first}
+	position := g.SimulateMove(first, true)
+
+	for len(pv) < maxLength && !position.IsGameOver() {
+		if len(position.ValidMoves(position.current)) == 0 {
+			pv = append(pv, Move{Pass: true})
+			position.SwitchTurn()
+
+			continue
+		}
+
+		entry, found := tt.Get(position.computeZobristHash())
+		if !found || entry.Depth == 0 {
+			break
+		}
+
+		flips := position.Flips(entry.BestMove.X, entry.BestMove.Y, position.current)
+		if flips == 0 {
+			break
+		}
+
+		move := Move{X: entry.BestMove.X, Y: entry.BestMove.Y, Flips: flips}
+		pv = append(pv, move)
+		position.MakeMove(move, true)
+	}
+
+	return pv
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSearchProgress(t *testing.T) {
+	g := deterministicGame(t, "f5d6c3d3c4", 5, 2)
+
+	var reports []SearchInfo
+	g.progress = func(info SearchInfo) {
+		reports = append(reports, info)
+	}
+
+	move := g.BestMove(context.Background())
+
+	if len(reports) == 0 {
+		t.Fatal("no progress reported")
+	}
+
+	last := reports[len(reports)-1]
+	if last.Depth != 5 || last.Move != move || len(last.PV) == 0 {
+		t.Errorf("last report %+v, want depth 5 with the best move %s", last, SquareName(move.X, move.Y))
+	}
+
+	for i := 1; i < len(reports); i++ {
+		if reports[i].Depth < reports[i-1].Depth || reports[i].Nodes < reports[i-1].Nodes {
+			t.Errorf("report %d (%+v) goes back from %+v", i, reports[i], reports[i-1])
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// Searcher owns the tables used by the searches of one AI player. The
+// transposition table is kept from one search to the next, so work done for
+// earlier moves of a game is reused. Searches on the same Searcher run one at a
+// time, so it may be shared by copies of a game that are searched from
+// different goroutines, while games and players with their own Searcher search
+// concurrently without sharing any state.
+type Searcher struct {
+	mu         sync.Mutex
+	tt         *transTable    // Allocated by the first search
+	weights    [3]EvalWeights // Weights the entries of tt were computed with
+	randomness float64        // Noise the entries of tt were computed with
+	seed       uint64         // Seed of that noise
+}
+
+// NewSearcher returns a Searcher with empty tables
+func NewSearcher() *Searcher {
+	return &Searcher{}
+}
+
+// begin waits for any other search on s to finish and returns the
+// transposition table for a new search with engine, and the seed of the
+// evaluation noise the table's entries were computed with. The table is replaced
+// if engine asks for another size or evaluates positions differently. end must
+// be called when the search is done.
+func (s *Searcher) begin(engine *EngineConfig) (*transTable, uint64) {
+	s.mu.Lock()
+
+	size := engine.HashSize
+	if size <= 0 {
+		size = DefaultHashSize
+	}
+
+	if s.tt == nil || s.tt.megabytes != size || s.weights != engine.Weights || s.randomness != engine.Randomness {
+		s.tt = newTransTable(size)
+		s.weights, s.randomness = engine.Weights, engine.Randomness
+		s.seed = rand.Uint64()
+	} else {
+		s.tt.age++
+	}
+
+	return s.tt, s.seed
+}
+
+// end lets the next search on s begin
+func (s *Searcher) end() {
+	s.mu.Unlock()
+}
+
+// Stats returns the statistics of the transposition table, accumulated over
+// every search since it was allocated. It waits for a running search to finish.
+func (s *Searcher) Stats() TTStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.tt == nil {
+		return TTStats{}
+	}
+
+	return s.tt.stats()
+}
+
+// Searcher returns the Searcher of player's AI. Games that were not created by
+// NewGame get a new Searcher for every call.
+func (g *Game) Searcher(player int) *Searcher {
+	s := g.blackSearcher
+	if player == White {
+		s = g.whiteSearcher
+	}
+
+	if s == nil {
+		return NewSearcher()
+	}
+
+	return s
+}
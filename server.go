@@ -0,0 +1,476 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gameServer serves games over an HTTP JSON API. Each game has its own lock, so
+// a long AI search only holds up requests for the same game. Every game keeps
+// the transposition table of its AI between moves, so memory is bounded by the
+// number of games times the engine's table size.
+type gameServer struct {
+	engine   EngineConfig  // Engine used for AI moves unless a request overrides it
+	maxTime  time.Duration // Longest AI search a request may ask for
+	maxGames int           // Number of games the server holds at most
+	idle     time.Duration // Games unused for this long are dropped when the server is full
+
+	mu     sync.Mutex
+	games  map[string]*serverGame
+	nextID int
+}
+
+// serverGame is a game held by the server
+type serverGame struct {
+	mu       sync.Mutex
+	game     *Game
+	lastUsed time.Time // Time of the last request for the game; guarded by the server's lock
+}
+
+// gameJSON is the state of a game as returned by the API
+type gameJSON struct {
+	ID         string   `json:"id"`
+	Position   string   `json:"position"`
+	Board      []string `json:"board"` // Rows 1 to 8, X for Black, O for White and - for empty
+	Current    string   `json:"current"`
+	Black      int      `json:"black"`
+	White      int      `json:"white"`
+	GameOver   bool     `json:"gameOver"`
+	Winner     string   `json:"winner,omitempty"`
+	Start      string   `json:"start,omitempty"` // Position the game was set up from, if not the initial one
+	Transcript string   `json:"transcript"`      // Moves played from the start position
+	LegalMoves []string `json:"legalMoves"`
+}
+
+// legalMoveJSON is a legal move with the evaluation of the resulting position
+type legalMoveJSON struct {
+	Move       string          `json:"move"`
+	Flips      int             `json:"flips"`
+	Evaluation ScoreComponents `json:"evaluation"`
+}
+
+// aiMoveJSON is the answer to an AI move request
+type aiMoveJSON struct {
+	Move  string    `json:"move"`
+	Score *float64  `json:"score,omitempty"`
+	Depth int       `json:"depth,omitempty"`
+	Nodes int64     `json:"nodes"`
+	Game  *gameJSON `json:"game"`
+}
+
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", "localhost:8080", "address to listen on")
+	engineSpec := flags.String("engine", "hard", "default engine for AI moves: a difficulty name with optional overrides")
+	maxTime := flags.Duration("max-time", 30*time.Second, "longest search time a request may ask for")
+	maxGames := flags.Int("max-games", 1000, "number of games held at once; finished games should be deleted to make room")
+	idle := flags.Duration("idle", time.Hour, "time after which an unused game may be dropped to make room for a new one")
+	maxHash := flags.Int("max-hash", 4, "largest transposition table in megabytes kept for each game")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *maxTime <= 0 {
+		return fmt.Errorf("-max-time must be positive")
+	}
+
+	if *maxGames <= 0 {
+		return fmt.Errorf("-max-games must be positive")
+	}
+
+	if *maxHash <= 0 {
+		return fmt.Errorf("-max-hash must be positive")
+	}
+
+	engine, err := ParseEngineSpec(*engineSpec)
+	if err != nil {
+		return err
+	}
+
+	engine.HashSize = min(engine.HashSize, *maxHash)
+
+	s := &gameServer{engine: engine, maxTime: *maxTime, maxGames: *maxGames, idle: *idle, games: map[string]*serverGame{}}
+
+	log.Printf("listening on %s", *addr)
+
+	return http.ListenAndServe(*addr, s.handler())
+}
+
+// maxRequestBody is the largest request body in bytes the server reads
+const maxRequestBody = 64 << 10
+
+// handler routes the API endpoints
+func (s *gameServer) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /games", s.createGame)
+	mux.HandleFunc("GET /games/{id}", s.withGame(s.getGame))
+	mux.HandleFunc("DELETE /games/{id}", s.deleteGame)
+	mux.HandleFunc("GET /games/{id}/moves", s.withGame(s.legalMoves))
+	mux.HandleFunc("POST /games/{id}/moves", s.withGame(s.playMove))
+	mux.HandleFunc("POST /games/{id}/ai", s.withGame(s.aiMove))
+	mux.HandleFunc("POST /games/{id}/undo", s.withGame(s.undo))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxRequestBody)
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// httpError is an error with the status code to answer it with
+type httpError struct {
+	status int
+	err    error
+}
+
+func (e *httpError) Error() string {
+	return e.err.Error()
+}
+
+func badRequest(format string, args ...any) error {
+	return &httpError{http.StatusBadRequest, fmt.Errorf(format, args...)}
+}
+
+// writeJSON answers with value, or with an error object if err is set
+func writeJSON(w http.ResponseWriter, status int, value any, err error) {
+	if err != nil {
+		status = http.StatusInternalServerError
+
+		var he *httpError
+		if errors.As(err, &he) {
+			status = he.status
+		}
+
+		value = map[string]string{"error": err.Error()}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// readJSON decodes an optional request body into value
+func readJSON(r *http.Request, value any) error {
+	if err := json.NewDecoder(r.Body).Decode(value); err != nil && err != io.EOF {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return &httpError{http.StatusRequestEntityTooLarge, fmt.Errorf("request body larger than %d bytes", tooLarge.Limit)}
+		}
+
+		return badRequest("invalid request body: %v", err)
+	}
+
+	return nil
+}
+
+// withGame looks up the game named in the path and holds its lock while the
+// handler runs. A panicking handler is answered with an internal server error.
+func (s *gameServer) withGame(handle func(id string, g *Game, r *http.Request) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+
+		s.mu.Lock()
+		sg, ok := s.games[id]
+		if ok {
+			sg.lastUsed = time.Now()
+		}
+		s.mu.Unlock()
+
+		if !ok {
+			writeJSON(w, 0, nil, &httpError{http.StatusNotFound, fmt.Errorf("no game %q", id)})
+
+			return
+		}
+
+		value, err := func() (value any, err error) {
+			sg.mu.Lock()
+			defer sg.mu.Unlock()
+
+			// Answer a failed request with an error rather than dropping the connection
+			defer func() {
+				if p := recover(); p != nil {
+					log.Printf("game %s: %s %s: %v\n%s", id, r.Method, r.URL.Path, p, debug.Stack())
+					err = fmt.Errorf("internal error")
+				}
+			}()
+
+			return handle(id, sg.game, r)
+		}()
+
+		if r.Context().Err() != nil {
+			// The client has gone away, so there is no one to answer
+			return
+		}
+
+		writeJSON(w, http.StatusOK, value, err)
+	}
+}
+
+func (s *gameServer) createGame(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Start string `json:"start"` // Transcript or board string, empty for the initial position
+	}
+
+	if err := readJSON(r, &request); err != nil {
+		writeJSON(w, 0, nil, err)
+
+		return
+	}
+
+	g := NewGame()
+	g.book = DefaultBook()
+	g.threads = 1
+
+	if err := g.LoadStart(request.Start); err != nil {
+		writeJSON(w, 0, nil, badRequest("start position: %v", err))
+
+		return
+	}
+
+	s.mu.Lock()
+
+	if len(s.games) >= s.maxGames {
+		s.dropIdleGames(time.Now())
+	}
+
+	if len(s.games) >= s.maxGames {
+		s.mu.Unlock()
+		writeJSON(w, 0, nil, &httpError{http.StatusServiceUnavailable, fmt.Errorf("too many games, delete finished ones first")})
+
+		return
+	}
+
+	s.nextID++
+	id := strconv.Itoa(s.nextID)
+	s.games[id] = &serverGame{game: g, lastUsed: time.Now()}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, gameState(id, g), nil)
+}
+
+// dropIdleGames forgets the games that have not been used for s.idle; s.mu must be held
+func (s *gameServer) dropIdleGames(now time.Time) {
+	for id, sg := range s.games {
+		if now.Sub(sg.lastUsed) >= s.idle {
+			delete(s.games, id)
+		}
+	}
+}
+
+func (s *gameServer) getGame(id string, g *Game, r *http.Request) (any, error) {
+	return gameState(id, g), nil
+}
+
+func (s *gameServer) deleteGame(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	s.mu.Lock()
+	_, ok := s.games[id]
+	delete(s.games, id)
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, 0, nil, &httpError{http.StatusNotFound, fmt.Errorf("no game %q", id)})
+
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// legalMoves lists the legal moves, each with the evaluation of the position it
+// leads to from the point of view of the player making it
+func (s *gameServer) legalMoves(id string, g *Game, r *http.Request) (any, error) {
+	moves := []legalMoveJSON{}
+
+	for _, move := range g.ValidMoves(g.current) {
+		moves = append(moves, legalMoveJSON{
+			Move:       SquareName(move.X, move.Y),
+			Flips:      popCount(move.Flips),
+			Evaluation: g.SimulateMove(move, false).EvaluateDetailed(g.current),
+		})
+	}
+
+	return moves, nil
+}
+
+// playMove plays the move in the body, e.g. {"move": "f5"} or {"move": "pass"}
+func (s *gameServer) playMove(id string, g *Game, r *http.Request) (any, error) {
+	var request struct {
+		Move string `json:"move"`
+	}
+
+	if err := readJSON(r, &request); err != nil {
+		return nil, err
+	}
+
+	if g.IsGameOver() {
+		return nil, badRequest("the game is over")
+	}
+
+	if strings.EqualFold(request.Move, "pass") {
+		if len(g.ValidMoves(g.current)) > 0 {
+			return nil, badRequest("pass with legal moves available")
+		}
+
+		g.Pass()
+
+		return gameState(id, g), nil
+	}
+
+	x, y, err := ParseSquare(request.Move)
+	if err != nil {
+		return nil, badRequest("%v", err)
+	}
+
+	if err := g.playSquare(x, y); err != nil {
+		return nil, badRequest("%v", err)
+	}
+
+	return gameState(id, g), nil
+}
+
+// aiMove lets the engine play for the side to move. The body may override the
+// engine with {"engine": "brutal"}, and limit the search with {"depth": 6} and
+// {"time": "2s"}. The depth is capped at 64 plies and the time at the server's
+// maximum; engine settings that choose the table size or thread count, or read
+// files, are refused.
+func (s *gameServer) aiMove(id string, g *Game, r *http.Request) (any, error) {
+	var request struct {
+		Engine string `json:"engine"`
+		Depth  int    `json:"depth"`
+		Time   string `json:"time"`
+	}
+
+	if err := readJSON(r, &request); err != nil {
+		return nil, err
+	}
+
+	if g.IsGameOver() {
+		return nil, badRequest("the game is over")
+	}
+
+	engine := s.engine
+
+	if request.Engine != "" {
+		// Clients may not pick the table size or thread count, or make the server read files
+		for _, field := range strings.Split(request.Engine, ",")[1:] {
+			if key, _, _ := strings.Cut(strings.TrimSpace(field), "="); key == "hash" || key == "threads" || key == "weights" {
+				return nil, badRequest("the %s setting is not allowed", key)
+			}
+		}
+
+		var err error
+
+		if engine, err = ParseEngineSpec(request.Engine); err != nil {
+			return nil, badRequest("%v", err)
+		}
+
+		engine.HashSize, engine.Threads = s.engine.HashSize, s.engine.Threads
+	}
+
+	if request.Depth < 0 {
+		return nil, badRequest("invalid depth %d", request.Depth)
+	} else if request.Depth > 0 {
+		engine.Depth = request.Depth
+	}
+
+	engine.Depth = min(engine.Depth, BoardSize*BoardSize)
+
+	if request.Time != "" {
+		moveTime, err := time.ParseDuration(request.Time)
+		if err != nil || moveTime <= 0 {
+			return nil, badRequest("invalid time %q", request.Time)
+		}
+
+		engine.MoveTime = moveTime
+	}
+
+	engine.MoveTime = min(engine.MoveTime, s.maxTime)
+
+	search := g.Copy()
+	search.SetEngine(search.current, engine)
+
+	var info SearchInfo
+	search.progress = func(i SearchInfo) {
+		info = i
+	}
+
+	// The search stops early if the client goes away
+	move := search.BestMove(r.Context())
+	if err := r.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	g.Play(move)
+
+	result := aiMoveJSON{Move: "pass", Nodes: info.Nodes, Game: gameState(id, g)}
+
+	if !move.Pass {
+		result.Move = SquareName(move.X, move.Y)
+	}
+
+	if info.Depth > 0 && info.Move == move {
+		result.Score, result.Depth = &info.Score, info.Depth
+	}
+
+	return result, nil
+}
+
+func (s *gameServer) undo(id string, g *Game, r *http.Request) (any, error) {
+	if !g.Undo() {
+		return nil, badRequest("nothing to undo")
+	}
+
+	return gameState(id, g), nil
+}
+
+// gameState describes the game for the API
+func gameState(id string, g *Game) *gameJSON {
+	position := g.Position()
+	blackScore, whiteScore := g.GetScore()
+
+	state := &gameJSON{
+		ID:         id,
+		Position:   position,
+		Current:    strings.ToLower(g.PlayerName(g.current)),
+		Black:      blackScore,
+		White:      whiteScore,
+		GameOver:   g.IsGameOver(),
+		Transcript: g.Transcript(),
+		LegalMoves: []string{},
+	}
+
+	if !g.StartsFromInitial() {
+		state.Start = FormatPosition(&g.start, g.startPlayer)
+	}
+
+	for y := 0; y < BoardSize; y++ {
+		state.Board = append(state.Board, position[y*BoardSize:(y+1)*BoardSize])
+	}
+
+	for _, move := range g.ValidMoves(g.current) {
+		state.LegalMoves = append(state.LegalMoves, SquareName(move.X, move.Y))
+	}
+
+	if state.GameOver {
+		state.Winner = "draw"
+
+		if winner := g.GetWinner(); winner != Blank {
+			state.Winner = strings.ToLower(g.PlayerName(winner))
+		}
+	}
+
+	return state
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestServer(maxGames int) *gameServer {
+	return &gameServer{engine: DifficultyLevels[0].Config(), maxTime: time.Second, maxGames: maxGames, idle: time.Hour, games: map[string]*serverGame{}}
+}
+
+// request sends a request to the server's handler and decodes the JSON answer
+// into value unless it is nil
+func request(t *testing.T, s *gameServer, method, path, body string, value any) int {
+	t.Helper()
+
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	s.handler().ServeHTTP(w, r)
+
+	if value != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), value); err != nil {
+			t.Fatalf("%s %s: %v in %q", method, path, err, w.Body.String())
+		}
+	}
+
+	return w.Code
+}
+
+func TestServerGame(t *testing.T) {
+	s := newTestServer(10)
+
+	var state gameJSON
+	if status := request(t, s, "POST", "/games", `{"start": "f5"}`, &state); status != http.StatusCreated {
+		t.Fatalf("create: status %d", status)
+	}
+
+	if state.Transcript != "f5" || state.Current != "white" || len(state.LegalMoves) != 3 || state.Start != "" {
+		t.Errorf("created %+v, want a game after f5", state)
+	}
+
+	path := "/games/" + state.ID
+
+	var moves []legalMoveJSON
+	if status := request(t, s, "GET", path+"/moves", "", &moves); status != http.StatusOK || len(moves) != 3 {
+		t.Errorf("moves: status %d, %d moves, want 3", status, len(moves))
+	}
+
+	if status := request(t, s, "POST", path+"/moves", `{"move": "d6"}`, &state); status != http.StatusOK || state.Transcript != "f5d6" {
+		t.Errorf("play d6: status %d, transcript %q", status, state.Transcript)
+	}
+
+	var failure map[string]string
+	if status := request(t, s, "POST", path+"/moves", `{"move": "a1"}`, &failure); status != http.StatusBadRequest || failure["error"] == "" {
+		t.Errorf("play a1: status %d, answer %v, want an error", status, failure)
+	}
+
+	if status := request(t, s, "POST", path+"/moves", `{"move": `, nil); status != http.StatusBadRequest {
+		t.Errorf("play with a broken body: status %d", status)
+	}
+
+	var ai aiMoveJSON
+	if status := request(t, s, "POST", path+"/ai", `{"depth": 2}`, &ai); status != http.StatusOK || len(ai.Game.Transcript) != 6 || !strings.HasSuffix(ai.Game.Transcript, ai.Move) {
+		t.Errorf("ai: status %d, %+v", status, ai)
+	}
+
+	for _, body := range []string{`{"engine": "easy,hash=1024"}`, `{"engine": "easy,threads=8"}`, `{"engine": "easy,weights=/etc/passwd"}`, `{"depth": -1}`, `{"time": "forever"}`} {
+		if status := request(t, s, "POST", path+"/ai", body, nil); status != http.StatusBadRequest {
+			t.Errorf("ai %s: status %d, want %d", body, status, http.StatusBadRequest)
+		}
+	}
+
+	if status := request(t, s, "POST", path+"/undo", "", &state); status != http.StatusOK || state.Transcript != "f5d6" {
+		t.Errorf("undo: status %d, transcript %q", status, state.Transcript)
+	}
+
+	if status := request(t, s, "GET", path, "", &state); status != http.StatusOK || state.Transcript != "f5d6" {
+		t.Errorf("get: status %d, transcript %q", status, state.Transcript)
+	}
+
+	if status := request(t, s, "DELETE", path, "", nil); status != http.StatusNoContent {
+		t.Errorf("delete: status %d", status)
+	}
+
+	if status := request(t, s, "GET", path, "", nil); status != http.StatusNotFound {
+		t.Errorf("get after delete: status %d", status)
+	}
+
+	if status := request(t, s, "DELETE", path, "", nil); status != http.StatusNotFound {
+		t.Errorf("second delete: status %d", status)
+	}
+}
+
+func TestServerLimits(t *testing.T) {
+	s := newTestServer(1)
+
+	large := `{"start": "` + strings.Repeat(" ", maxRequestBody) + `"}`
+	if status := request(t, s, "POST", "/games", large, nil); status != http.StatusRequestEntityTooLarge {
+		t.Errorf("create with a large body: status %d", status)
+	}
+
+	if status := request(t, s, "POST", "/games", `{"start": "a1"}`, nil); status != http.StatusBadRequest {
+		t.Errorf("create from an illegal transcript: status %d", status)
+	}
+
+	if status := request(t, s, "POST", "/games", "", nil); status != http.StatusCreated {
+		t.Fatalf("create: status %d", status)
+	}
+
+	if status := request(t, s, "POST", "/games", "", nil); status != http.StatusServiceUnavailable {
+		t.Errorf("create with the server full: status %d", status)
+	}
+
+	// An idle game makes room for a new one
+	for _, sg := range s.games {
+		sg.lastUsed = time.Now().Add(-2 * s.idle)
+	}
+
+	if status := request(t, s, "POST", "/games", "", nil); status != http.StatusCreated || len(s.games) != 1 {
+		t.Errorf("create after the game went idle: status %d with %d games", status, len(s.games))
+	}
+}
+
+func TestServerCancelledRequest(t *testing.T) {
+	s := newTestServer(1)
+
+	var state gameJSON
+	request(t, s, "POST", "/games", "", &state)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r := httptest.NewRequestWithContext(ctx, "POST", "/games/"+state.ID+"/ai", strings.NewReader(`{"depth": 8}`))
+	w := httptest.NewRecorder()
+
+	s.handler().ServeHTTP(w, r)
+
+	if w.Body.Len() != 0 {
+		t.Errorf("cancelled request answered with %q", w.Body.String())
+	}
+
+	if status := request(t, s, "GET", "/games/"+state.ID, "", &state); status != http.StatusOK || state.Transcript != "" {
+		t.Errorf("game after a cancelled AI move: status %d, transcript %q", status, state.Transcript)
+	}
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// tournamentGame is one game of a tournament, starting after the opening moves
+type tournamentGame struct {
+	number  int
+	opening string // Transcript of the opening moves
+	aBlack  bool   // Engine A plays Black
+}
+
+// tournamentResult is the outcome of a game from engine A's point of view
+type tournamentResult struct {
+	game   tournamentGame
+	margin int // Engine A's discs minus engine B's
+	moves  string
+	err    error
+}
+
+// TournamentStats accumulates results from engine A's point of view
+type TournamentStats struct {
+	Wins, Draws, Losses int
+	MarginSum           int
+}
+
+// Add records the result of one game with the given disc margin
+func (s *TournamentStats) Add(margin int) {
+	switch {
+	case margin > 0:
+		s.Wins++
+	case margin < 0:
+		s.Losses++
+	default:
+		s.Draws++
+	}
+
+	s.MarginSum += margin
+}
+
+// Games returns the number of games recorded
+func (s *TournamentStats) Games() int {
+	return s.Wins + s.Draws + s.Losses
+}
+
+// Elo returns the Elo difference between engine A and engine B implied by the
+// score, with the half-width of its 95% confidence interval. When one engine
+// scored every point the difference is +Inf or -Inf and the half-width +Inf;
+// with no games the difference is 0 and the half-width +Inf.
+func (s *TournamentStats) Elo() (float64, float64) {
+	n := float64(s.Games())
+	if n == 0 {
+		return 0, math.Inf(1)
+	}
+
+	score := (float64(s.Wins) + float64(s.Draws)/2) / n
+	if score == 0 || score == 1 {
+		return eloDifference(score), math.Inf(1)
+	}
+
+	// Standard deviation of the mean score per game
+	variance := (float64(s.Wins)*math.Pow(1-score, 2) +
+		float64(s.Draws)*math.Pow(0.5-score, 2) +
+		float64(s.Losses)*math.Pow(score, 2)) / n
+	deviation := math.Sqrt(variance / n)
+
+	low, high := eloDifference(score-1.96*deviation), eloDifference(score+1.96*deviation)
+
+	return eloDifference(score), (high - low) / 2
+}
+
+// eloDifference converts an expected score into an Elo difference
+func eloDifference(score float64) float64 {
+	switch {
+	case score <= 0:
+		return math.Inf(-1)
+	case score >= 1:
+		return math.Inf(1)
+	default:
+		return 400 * math.Log10(score/(1-score))
+	}
+}
+
+// runTournament implements the tournament command
+func runTournament(args []string) error {
+	flags := flag.NewFlagSet("tournament", flag.ContinueOnError)
+	specA := flags.String("a", "hard", "engine A: a difficulty name with optional overrides, e.g. hard,depth=7,time=1s,random=50,weights=w.txt")
+	specB := flags.String("b", "medium", "engine B, in the same format as -a")
+	games := flags.Int("games", 20, "number of games; each opening is played twice with colors swapped")
+	parallel := flags.Int("parallel", runtime.NumCPU(), "number of games played at the same time")
+	openingsPath := flags.String("openings", "", "file with one opening transcript per line; random openings if empty")
+	randomPlies := flags.Int("random-plies", 4, "number of random moves in generated openings")
+	noBook := flags.Bool("no-book", false, "do not let the engines play from the opening book")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	engineA, err := ParseEngineSpec(*specA)
+	if err != nil {
+		return fmt.Errorf("engine A: %w", err)
+	}
+
+	engineB, err := ParseEngineSpec(*specB)
+	if err != nil {
+		return fmt.Errorf("engine B: %w", err)
+	}
+
+	var openings []string
+
+	if *openingsPath != "" {
+		f, err := os.Open(*openingsPath)
+		if err != nil {
+			return err
+		}
+
+		openings, err = readOpenings(f)
+		f.Close()
+
+		if err != nil {
+			return fmt.Errorf("%s: %w", *openingsPath, err)
+		}
+	}
+
+	var book *OpeningBook
+	if !*noBook {
+		book = DefaultBook()
+	}
+
+	// Pair up the games so that both engines play each opening with both colors
+	schedule := make([]tournamentGame, *games)
+
+	for i := range schedule {
+		if i%2 == 0 {
+			if openings != nil {
+				schedule[i].opening = openings[i/2%len(openings)]
+			} else {
+				schedule[i].opening = randomOpening(*randomPlies)
+			}
+		} else {
+			schedule[i].opening = schedule[i-1].opening
+		}
+
+		schedule[i].number = i + 1
+		schedule[i].aBlack = i%2 == 0
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	jobs := make(chan tournamentGame)
+	results := make(chan tournamentResult)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < max(*parallel, 1); i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for job := range jobs {
+				results <- playTournamentGame(ctx, job, engineA, engineB, book)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+
+		for _, job := range schedule {
+			select {
+			case jobs <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	fmt.Printf("A: %s\nB: %s\n\n", *specA, *specB)
+
+	var stats TournamentStats
+
+	for result := range results {
+		if result.err != nil {
+			return fmt.Errorf("game %d: %w", result.game.number, result.err)
+		}
+
+		if ctx.Err() != nil {
+			// Interrupted games are not counted
+			continue
+		}
+
+		stats.Add(result.margin)
+
+		colorA := "White"
+		if result.game.aBlack {
+			colorA = "Black"
+		}
+
+		fmt.Printf("Game %3d  A as %s  margin %+3d  %s\n", result.game.number, colorA, result.margin, result.moves)
+	}
+
+	printTournamentStats(os.Stdout, &stats)
+
+	return nil
+}
+
+// playTournamentGame plays one game between the two engines
+func playTournamentGame(ctx context.Context, job tournamentGame, engineA, engineB EngineConfig, book *OpeningBook) tournamentResult {
+	g := NewGame()
+	g.book = book
+	g.threads = 1
+	g.blackAI, g.whiteAI = true, true
+
+	if err := g.LoadTranscript(job.opening); err != nil {
+		return tournamentResult{game: job, err: fmt.Errorf("opening %q: %w", job.opening, err)}
+	}
+
+	playerA := White
+	if job.aBlack {
+		playerA = Black
+	}
+
+	g.SetEngine(playerA, engineA)
+	g.SetEngine(Opponent(playerA), engineB)
+
+	for !g.IsGameOver() && ctx.Err() == nil {
+		g.Play(g.BestMove(ctx))
+	}
+
+	margin := popCount(g.board.Discs(playerA)) - popCount(g.board.Discs(Opponent(playerA)))
+
+	return tournamentResult{game: job, margin: margin, moves: g.Transcript()}
+}
+
+// printTournamentStats summarizes the results from engine A's point of view
+func printTournamentStats(w io.Writer, stats *TournamentStats) {
+	games := stats.Games()
+	if games == 0 {
+		fmt.Fprintln(w, "\nNo games completed")
+
+		return
+	}
+
+	elo, errorBar := stats.Elo()
+
+	fmt.Fprintf(w, "\nGames: %d\n", games)
+	fmt.Fprintf(w, "A wins: %d  draws: %d  losses: %d\n", stats.Wins, stats.Draws, stats.Losses)
+	fmt.Fprintf(w, "Average disc margin: %+.2f\n", float64(stats.MarginSum)/float64(games))
+
+	if math.IsInf(elo, 0) {
+		fmt.Fprintf(w, "Elo difference: %+.0f, no bound as one engine scored every point\n", elo)
+
+		return
+	}
+
+	fmt.Fprintf(w, "Elo difference: %+.0f ± %.0f (95%%)\n", elo, errorBar)
+}
+
+// readOpenings reads one opening transcript per line, ignoring blank lines and
+// text after '#'
+func readOpenings(r io.Reader) ([]string, error) {
+	var openings []string
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		if line = strings.TrimSpace(line); line != "" {
+			openings = append(openings, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(openings) == 0 {
+		return nil, fmt.Errorf("no openings found")
+	}
+
+	return openings, nil
+}
+
+// randomOpening plays up to plies random legal moves from the initial position
+// and returns them as a transcript
+func randomOpening(plies int) string {
+	g := NewGame()
+
+	for i := 0; i < plies && !g.IsGameOver(); i++ {
+		moves := g.ValidMoves(g.current)
+		if len(moves) == 0 {
+			g.Pass()
+
+			continue
+		}
+
+		g.Play(moves[rand.Intn(len(moves))])
+	}
+
+	return g.Transcript()
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestElo(t *testing.T) {
+	tests := []struct {
+		name                string
+		wins, draws, losses int
+		elo, margin         float64 // Margin 0 is not checked
+	}{
+		{"no games", 0, 0, 0, 0, math.Inf(1)},
+		{"even", 5, 0, 5, 0, 0},
+		{"all draws", 0, 4, 0, 0, 0},
+		{"three to one", 30, 0, 10, 400 * math.Log10(3), 0},
+		{"all wins", 10, 0, 0, math.Inf(1), math.Inf(1)},
+		{"all losses", 0, 0, 10, math.Inf(-1), math.Inf(1)},
+		{"wins and draws", 3, 2, 0, 400 * math.Log10(4), 0},
+	}
+
+	for _, test := range tests {
+		stats := TournamentStats{Wins: test.wins, Draws: test.draws, Losses: test.losses}
+		elo, margin := stats.Elo()
+
+		if math.IsNaN(elo) || math.IsNaN(margin) {
+			t.Errorf("%s: Elo() = %v ± %v", test.name, elo, margin)
+
+			continue
+		}
+
+		if elo != test.elo && math.Abs(elo-test.elo) > 1e-9 {
+			t.Errorf("%s: Elo difference = %v, want %v", test.name, elo, test.elo)
+		}
+
+		if test.margin != 0 && margin != test.margin {
+			t.Errorf("%s: error bar = %v, want %v", test.name, margin, test.margin)
+		}
+
+		if margin < 0 {
+			t.Errorf("%s: negative error bar %v", test.name, margin)
+		}
+	}
+}
+
+func TestEloMarginShrinks(t *testing.T) {
+	few := TournamentStats{Wins: 6, Losses: 4}
+	many := TournamentStats{Wins: 600, Losses: 400}
+
+	_, fewMargin := few.Elo()
+	_, manyMargin := many.Elo()
+
+	if !(manyMargin < fewMargin) {
+		t.Errorf("error bar for 1000 games %v is not below the one for 10 games %v", manyMargin, fewMargin)
+	}
+}
+
+func TestPrintTournamentStats(t *testing.T) {
+	var sb strings.Builder
+
+	stats := TournamentStats{}
+	stats.Add(10)
+	stats.Add(4)
+
+	printTournamentStats(&sb, &stats)
+
+	if out := sb.String(); strings.Contains(out, "NaN") || !strings.Contains(out, "A wins: 2  draws: 0  losses: 0") || !strings.Contains(out, "+7.00") {
+		t.Errorf("printTournamentStats output:\n%s", out)
+	}
+}
+
+func TestReadOpenings(t *testing.T) {
+	openings, err := readOpenings(strings.NewReader("f5d6 # tiger\n\n  f5f6  \n# comment only\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(openings, ",") != "f5d6,f5f6" {
+		t.Errorf("readOpenings = %q, want f5d6 and f5f6", openings)
+	}
+
+	if _, err := readOpenings(strings.NewReader("# nothing\n")); err == nil {
+		t.Error("readOpenings without openings succeeded")
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SquareName returns the a1..h8 name of (x, y)
+func SquareName(x, y int) string {
+	return fmt.Sprintf("%c%d", 'a'+x, y+1)
+}
+
+// ParseSquare parses an a1..h8 square name (either case) into board coordinates
+func ParseSquare(s string) (int, int, error) {
+	s = strings.ToLower(s)
+
+	if len(s) != 2 || s[0] < 'a' || s[0] > 'h' || s[1] < '1' || s[1] > '8' {
+		return 0, 0, fmt.Errorf("invalid square %q", s)
+	}
+
+	return int(s[0] - 'a'), int(s[1] - '1'), nil
+}
+
+// Transcript returns the moves played so far in the usual Othello notation,
+// e.g. "f5d6c3d3c4". Passes are implicit and therefore omitted. The moves are
+// played from the start position, so the transcript only describes the whole
+// game if StartsFromInitial reports true.
+func (g *Game) Transcript() string {
+	var sb strings.Builder
+
+	for _, entry := range g.history {
+		if !entry.Move.Pass {
+			sb.WriteString(SquareName(entry.Move.X, entry.Move.Y))
+		}
+	}
+
+	return sb.String()
+}
+
+// StartsFromInitial reports whether the game started from the initial position
+// with Black to move, as every transcript does
+func (g *Game) StartsFromInitial() bool {
+	return g.start == *NewBoard() && g.startPlayer == Black
+}
+
+// LoadTranscript replaces the game with the one described by transcript, starting
+// from the initial position. Passes are inserted wherever the side to move has no
+// legal move. The game is left untouched if the transcript contains an illegal move.
+func (g *Game) LoadTranscript(transcript string) error {
+	replay := g.Copy()
+	replay.Reset()
+
+	transcript = strings.Join(strings.Fields(transcript), "")
+
+	if len(transcript)%2 != 0 {
+		return fmt.Errorf("transcript has odd length %d", len(transcript))
+	}
+
+	for i := 0; i < len(transcript); i += 2 {
+		x, y, err := ParseSquare(transcript[i : i+2])
+		if err != nil {
+			return fmt.Errorf("move %d: %w", i/2+1, err)
+		}
+
+		if err := replay.playSquare(x, y); err != nil {
+			return fmt.Errorf("move %d: %w", i/2+1, err)
+		}
+	}
+
+	replay.passIfStuck()
+
+	g.replaceWith(replay)
+
+	return nil
+}
+
+// playSquare plays the current player's disc on (x, y), first passing if the
+// current player has no legal move. The game is left untouched if the move is
+// illegal.
+func (g *Game) playSquare(x, y int) error {
+	player := g.current
+	stuck := len(g.ValidMoves(player)) == 0 && !g.IsGameOver()
+
+	if stuck {
+		player = Opponent(player)
+	}
+
+	flips := g.Flips(x, y, player)
+	if flips == 0 {
+		return fmt.Errorf("%s is not a legal move for %s", SquareName(x, y), g.PlayerName(player))
+	}
+
+	if stuck {
+		g.Pass()
+	}
+
+	g.Play(Move{X: x, Y: y, Flips: flips})
+
+	return nil
+}
+
+// passIfStuck records a pass if the current player has no legal move but the game is not over
+func (g *Game) passIfStuck() {
+	if len(g.ValidMoves(g.current)) == 0 && !g.IsGameOver() {
+		g.Pass()
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSquare(t *testing.T) {
+	tests := []struct {
+		square string
+		x, y   int
+		valid  bool
+	}{
+		{"a1", 0, 0, true},
+		{"h8", 7, 7, true},
+		{"F5", 5, 4, true},
+		{"d3", 3, 2, true},
+		{"i1", 0, 0, false},
+		{"a9", 0, 0, false},
+		{"a", 0, 0, false},
+		{"", 0, 0, false},
+	}
+
+	for _, test := range tests {
+		x, y, err := ParseSquare(test.square)
+
+		if (err == nil) != test.valid {
+			t.Errorf("ParseSquare(%q) error = %v, want valid %v", test.square, err, test.valid)
+
+			continue
+		}
+
+		if test.valid && (x != test.x || y != test.y) {
+			t.Errorf("ParseSquare(%q) = %d, %d, want %d, %d", test.square, x, y, test.x, test.y)
+		}
+
+		if test.valid && SquareName(x, y) != strings.ToLower(test.square) {
+			t.Errorf("SquareName(%d, %d) = %q, want %q", x, y, SquareName(x, y), test.square)
+		}
+	}
+}
+
+func TestLoadTranscript(t *testing.T) {
+	tests := []struct {
+		transcript string
+		want       string // Transcript after loading
+		plies      int    // History entries, passes included
+		passes     int
+		valid      bool
+	}{
+		{"", "", 0, 0, true},
+		{"f5d6c3", "f5d6c3", 3, 0, true},
+		{"F5 d6\nC3", "f5d6c3", 3, 0, true},
+		{passTranscript, passTranscript, 10, 1, true},
+		{"f5a1", "", 0, 0, false},
+		{"f5d6z9", "", 0, 0, false},
+		{"f5d", "", 0, 0, false},
+	}
+
+	for _, test := range tests {
+		g := NewGame()
+		g.Play(g.ValidMoves(g.current)[0])
+		before := g.Transcript()
+
+		err := g.LoadTranscript(test.transcript)
+
+		if (err == nil) != test.valid {
+			t.Errorf("LoadTranscript(%q) error = %v, want valid %v", test.transcript, err, test.valid)
+
+			continue
+		}
+
+		if !test.valid {
+			if g.Transcript() != before {
+				t.Errorf("LoadTranscript(%q) changed the game to %q after failing", test.transcript, g.Transcript())
+			}
+
+			continue
+		}
+
+		passes := 0
+
+		for _, entry := range g.History() {
+			if entry.Move.Pass {
+				passes++
+			}
+		}
+
+		if got := g.Transcript(); got != test.want {
+			t.Errorf("LoadTranscript(%q): Transcript() = %q, want %q", test.transcript, got, test.want)
+		}
+
+		if len(g.History()) != test.plies || passes != test.passes {
+			t.Errorf("LoadTranscript(%q): %d plies with %d passes, want %d with %d", test.transcript, len(g.History()), passes, test.plies, test.passes)
+		}
+
+		if !g.StartsFromInitial() {
+			t.Errorf("LoadTranscript(%q): game does not start from the initial position", test.transcript)
+		}
+	}
+}
+
+func TestPlaySquareAfterForcedPass(t *testing.T) {
+	// White fills every square but e8 to h8 and Black's d4, so White has no
+	// legal move and Black can only play h8
+	g := NewGame()
+	g.board = &Board{black: squareBit(3, 3), white: (1<<60 - 1) &^ squareBit(3, 3)}
+	g.current = White
+
+	if err := g.playSquare(0, 0); err == nil {
+		t.Fatal("playSquare(a1) succeeded on an occupied square")
+	}
+
+	if len(g.History()) != 0 || g.current != White {
+		t.Fatalf("failed playSquare changed the game: %d plies, %s to move", len(g.History()), g.PlayerName(g.current))
+	}
+
+	x, y, _ := ParseSquare("h8")
+	if err := g.playSquare(x, y); err != nil {
+		t.Fatalf("playSquare(h8) = %v", err)
+	}
+
+	if history := g.History(); len(history) != 2 || !history[0].Move.Pass || history[1].Player != Black {
+		t.Errorf("playSquare(h8) history = %+v, want a White pass and a Black move", history)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"math/bits"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+const (
+	ttBucketSize = 4    // Entries that compete for the slots of one bucket
+	ttLocks      = 1024 // Locks striped over the buckets so that parallel workers rarely wait on each other
+)
+
+// DefaultHashSize is the transposition table size in megabytes used when an
+// engine configuration does not set one
+const DefaultHashSize = 16
+
+// maxHashSize is the largest transposition table size in megabytes an engine may ask for
+const maxHashSize = 16384
+
+// transTable is a fixed-size transposition table of a power of two buckets. A
+// position may be stored in any slot of the bucket its hash selects. When the
+// bucket is full, entries left over from earlier searches are replaced first,
+// then the shallowest ones, so the table can be kept from one search to the next.
+type transTable struct {
+	buckets   []ttBucket
+	mask      uint64
+	megabytes int
+	locks     [ttLocks]sync.Mutex
+	age       uint8 // Advanced for every search; changed only while no search runs
+	probes    atomic.Int64
+	hits      atomic.Int64
+}
+
+type ttBucket [ttBucketSize]ttSlot
+
+// ttSlot is a compact TTEntry. The best move is stored without its flips.
+type ttSlot struct {
+	key   uint64
+	eval  float64
+	depth int8
+	flag  uint8
+	x, y  int8
+	age   uint8 // Age of the search that last stored or found the entry
+	used  bool
+}
+
+// TTStats describes how well a transposition table is used
+type TTStats struct {
+	Bytes   int // Memory taken by the entries
+	Entries int // Number of entries the table can hold
+	Used    int // Number of entries filled
+	Probes  int64
+	Hits    int64 // Probes that found an entry for the position
+}
+
+// HitRate returns the share of probes that found an entry
+func (s TTStats) HitRate() float64 {
+	if s.Probes == 0 {
+		return 0
+	}
+
+	return float64(s.Hits) / float64(s.Probes)
+}
+
+// Fill returns the share of entries in use
+func (s TTStats) Fill() float64 {
+	if s.Entries == 0 {
+		return 0
+	}
+
+	return float64(s.Used) / float64(s.Entries)
+}
+
+// newTransTable allocates the largest table with a power of two buckets that
+// fits in the given number of megabytes
+func newTransTable(megabytes int) *transTable {
+	buckets := max(megabytes<<20/int(unsafe.Sizeof(ttBucket{})), 1)
+	size := 1 << (bits.Len(uint(buckets)) - 1)
+
+	return &transTable{buckets: make([]ttBucket, size), mask: uint64(size - 1), megabytes: megabytes}
+}
+
+// Get looks up the entry stored for a position hash, keeping it from being
+// replaced as stale by the current search
+func (t *transTable) Get(key uint64) (TTEntry, bool) {
+	index := key & t.mask
+	lock := &t.locks[index%ttLocks]
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	bucket := &t.buckets[index]
+
+	for i := range bucket {
+		slot := &bucket[i]
+
+		if slot.used && slot.key == key {
+			slot.age = t.age
+
+			return TTEntry{
+				Depth:    int(slot.depth),
+				Eval:     slot.eval,
+				Flag:     int(slot.flag),
+				BestMove: Move{X: int(slot.x), Y: int(slot.y)},
+			}, true
+		}
+	}
+
+	return TTEntry{}, false
+}
+
+// Put stores an entry. An entry for the same position is only replaced if the
+// new one is at least as deep or the old one is stale.
+func (t *transTable) Put(key uint64, entry TTEntry) {
+	index := key & t.mask
+	lock := &t.locks[index%ttLocks]
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	bucket := &t.buckets[index]
+	victim := -1
+
+	for i := range bucket {
+		if bucket[i].used && bucket[i].key == key {
+			if bucket[i].age == t.age && int(bucket[i].depth) > entry.Depth {
+				return
+			}
+
+			victim = i
+
+			break
+		}
+	}
+
+	if victim < 0 {
+		victim = 0
+
+		for i := 1; i < ttBucketSize; i++ {
+			if t.worth(&bucket[i]) < t.worth(&bucket[victim]) {
+				victim = i
+			}
+		}
+	}
+
+	bucket[victim] = ttSlot{
+		key:   key,
+		eval:  entry.Eval,
+		depth: int8(entry.Depth),
+		flag:  uint8(entry.Flag),
+		x:     int8(entry.BestMove.X),
+		y:     int8(entry.BestMove.Y),
+		age:   t.age,
+		used:  true,
+	}
+}
+
+// worth ranks how much a slot is worth keeping: empty slots least, then entries
+// from earlier searches, each by depth
+func (t *transTable) worth(slot *ttSlot) int {
+	switch {
+	case !slot.used:
+		return -1
+	case slot.age != t.age:
+		return int(slot.depth)
+	default:
+		return int(slot.depth) + 256
+	}
+}
+
+// record adds the probes counted by a search worker to the statistics
+func (t *transTable) record(probes, hits int64) {
+	t.probes.Add(probes)
+	t.hits.Add(hits)
+}
+
+// stats counts the entries in use; no search may be running
+func (t *transTable) stats() TTStats {
+	stats := TTStats{
+		Bytes:   len(t.buckets) * int(unsafe.Sizeof(ttBucket{})),
+		Entries: len(t.buckets) * ttBucketSize,
+		Probes:  t.probes.Load(),
+		Hits:    t.hits.Load(),
+	}
+
+	for i := range t.buckets {
+		for _, slot := range t.buckets[i] {
+			if slot.used {
+				stats.Used++
+			}
+		}
+	}
+
+	return stats
+}
@@ -0,0 +1,117 @@
+package main
+
+import "testing"
+
+func TestTransTablePut(t *testing.T) {
+	tt := newTransTable(1)
+	key := uint64(12345)
+
+	if _, ok := tt.Get(key); ok {
+		t.Fatal("Get found an entry in an empty table")
+	}
+
+	tt.Put(key, TTEntry{Depth: 4, Eval: 10, Flag: LowerBound, BestMove: Move{X: 2, Y: 3}})
+
+	entry, ok := tt.Get(key)
+	if !ok || entry.Depth != 4 || entry.Eval != 10 || entry.Flag != LowerBound || entry.BestMove.X != 2 || entry.BestMove.Y != 3 {
+		t.Fatalf("Get = %+v, %v after Put", entry, ok)
+	}
+
+	// A shallower entry from the same search does not replace a deeper one
+	tt.Put(key, TTEntry{Depth: 2, Eval: 20})
+
+	if entry, _ := tt.Get(key); entry.Depth != 4 {
+		t.Errorf("depth %d after a shallower Put, want 4", entry.Depth)
+	}
+
+	tt.Put(key, TTEntry{Depth: 6, Eval: 30})
+
+	if entry, _ := tt.Get(key); entry.Depth != 6 || entry.Eval != 30 {
+		t.Errorf("entry %+v after a deeper Put, want depth 6", entry)
+	}
+
+	// Once the search is over, the entry is stale and any new one replaces it
+	tt.age++
+	tt.Put(key, TTEntry{Depth: 1, Eval: 40})
+
+	if entry, _ := tt.Get(key); entry.Depth != 1 || entry.Eval != 40 {
+		t.Errorf("entry %+v after a Put in a new search, want depth 1", entry)
+	}
+}
+
+func TestTransTableReplacement(t *testing.T) {
+	tt := newTransTable(1)
+	stride := tt.mask + 1 // Keys this far apart share a bucket
+
+	for i := range ttBucketSize {
+		tt.Put(uint64(i)*stride, TTEntry{Depth: i + 1})
+	}
+
+	// The bucket is full, so the shallowest entry of the current search goes
+	tt.Put(ttBucketSize*stride, TTEntry{Depth: 3})
+
+	if _, ok := tt.Get(0); ok {
+		t.Error("the shallowest entry was kept")
+	}
+
+	for i := 1; i <= ttBucketSize; i++ {
+		if _, ok := tt.Get(uint64(i) * stride); !ok {
+			t.Errorf("entry %d was replaced", i)
+		}
+	}
+
+	// In the next search, entries that were not found again are replaced first,
+	// however deep they are
+	tt.age++
+
+	for i := 1; i < ttBucketSize; i++ {
+		tt.Get(uint64(i) * stride)
+	}
+
+	tt.Put((ttBucketSize+1)*stride, TTEntry{Depth: 1})
+
+	if _, ok := tt.Get(ttBucketSize * stride); ok {
+		t.Error("the stale entry was kept")
+	}
+
+	if _, ok := tt.Get((ttBucketSize + 1) * stride); !ok {
+		t.Error("the new entry was not stored")
+	}
+}
+
+func TestSearcherBegin(t *testing.T) {
+	s := NewSearcher()
+	engine := DifficultyLevels[0].Config()
+
+	tt, seed := s.begin(&engine)
+	s.end()
+
+	again, sameSeed := s.begin(&engine)
+	s.end()
+
+	if again != tt || sameSeed != seed || again.age != 1 {
+		t.Error("the table was not kept for a search with the same settings")
+	}
+
+	tests := []struct {
+		name   string
+		change func(c *EngineConfig)
+	}{
+		{"hash size", func(c *EngineConfig) { c.HashSize = 1 }},
+		{"weights", func(c *EngineConfig) { c.Weights[MidGame].Mobility++ }},
+		{"randomness", func(c *EngineConfig) { c.Randomness = 5 }},
+	}
+
+	for _, test := range tests {
+		test.change(&engine)
+
+		next, _ := s.begin(&engine)
+		s.end()
+
+		if next == tt {
+			t.Errorf("the table was kept after changing the %s", test.name)
+		}
+
+		tt = next
+	}
+}
@@ -1,7 +1,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -9,63 +16,164 @@ import (
 	"github.com/rivo/tview"
 )
 
-func (g *Game) StartUI() {
+// UIOptions preset the choices of the start screen
+type UIOptions struct {
+	Black, White int         // Indexes into playerTypeNames()
+	TimeControl  TimeControl // Offered along with TimeControls if it is not one of them
+}
+
+// DefaultUIOptions pairs a human playing Black against a medium AI, untimed
+var DefaultUIOptions = UIOptions{Black: 0, White: 2, TimeControl: TimeControls[0]}
+
+// StartUI runs the terminal UI. Every game starts from the position g is in
+// when StartUI is called.
+func (g *Game) StartUI(options UIOptions) {
 	app := tview.NewApplication()
 
-	// Variables to store selected options
-	var playerColorOption string
-	var difficultyOption string
+	// Variables to store selected options; playerTypes holds indexes into playerTypeNames()
+	var playerTypes = map[int]int{Black: options.Black, White: options.White}
+	var moveDelay = 500 * time.Millisecond // Pause between moves of AI-vs-AI games
+	var paused bool                        // Set while AI players are held
+	var step bool                          // Lets one AI move through while paused
 	var showValidMoves = true
+	var database *WthorDatabase
+	var timeControls, timeControl = TimeControls, 0 // Choices and index of the chosen one
+	var startBoard, startPlayer = *g.board, g.current
+	var analyzeOnStart bool       // Show hints for the first position, for positions set up to be analyzed
+	var analysisTypes map[int]int // Player types to restore after analyzing a set-up position
+	var clocks map[int]*Clock     // Nil when the game is untimed
+	var timeLoser = Blank         // Player who ran out of time, if any
+
+	if g.book == nil {
+		g.book = DefaultBook()
+	}
+
+	if tc := options.TimeControl; tc.Timed() {
+		if timeControl = slices.Index(TimeControls, tc); timeControl < 0 {
+			timeControls = append(slices.Clip(TimeControls), tc)
+			timeControl = len(timeControls) - 1
+		}
+	}
+
+	// Cancels the search of the AI that is currently thinking, if any
+	cancelSearch := func() {}
+
+	// Stops the goroutine that ticks the clocks of the current game, if any
+	stopClockTicker := func() {}
+
+	// startClock starts the clock of the player to move
+	startClock := func() {
+		if clocks != nil {
+			clocks[g.current].Start(time.Now())
+		}
+	}
+
+	// haltClocks halts the running clock with Clock.Stop at the end of a move or
+	// Clock.Pause when play is interrupted, and reports false if its player has
+	// run out of time
+	haltClocks := func(halt func(*Clock, time.Time) bool) bool {
+		if clocks == nil {
+			return true
+		}
+
+		now := time.Now()
+
+		for player, clock := range clocks {
+			if !halt(clock, now) {
+				timeLoser = player
+
+				return false
+			}
+		}
+
+		return true
+	}
+
+	stopClocks := func() bool {
+		return haltClocks((*Clock).Stop)
+	}
+
+	// pauseClocks halts the clocks without granting an increment, for actions
+	// that are not moves
+	pauseClocks := func() bool {
+		return haltClocks((*Clock).Pause)
+	}
 
 	// Start with the start screen
 	var showStartScreen func()
 	var startGame func()
 	var gameOver func()
 
+	// beginGame applies the chosen player types and starts a game
+	beginGame := func() {
+		// Any player type but the first is an AI
+		g.blackAI = playerTypes[Black] > 0
+		g.whiteAI = playerTypes[White] > 0
+
+		for _, player := range []int{Black, White} {
+			if g.IsAI(player) {
+				g.SetDifficulty(player, playerTypes[player]-1)
+			}
+		}
+		paused, step = false, false
+
+		startGame()
+	}
+
 	showStartScreen = func() {
+		if analysisTypes != nil {
+			playerTypes, analysisTypes = analysisTypes, nil
+		}
+
 		form := tview.NewForm()
 		form.
-			AddDropDown("Choose your color", []string{"Black", "White"}, 0, func(option string, index int) {
-				playerColorOption = option
+			AddDropDown("Black player", playerTypeNames(), playerTypes[Black], func(option string, index int) {
+				playerTypes[Black] = index
+			}).
+			AddDropDown("White player", playerTypeNames(), playerTypes[White], func(option string, index int) {
+				playerTypes[White] = index
 			}).
-			AddDropDown("Difficulty", []string{"Easy", "Medium", "Hard", "Brutal", "Extreme"}, 1, func(option string, index int) {
-				difficultyOption = option
+			AddDropDown("Time control", timeControlNames(timeControls), timeControl, func(option string, index int) {
+				timeControl = index
 			}).
 			AddCheckbox("Show valid moves", true, func(checked bool) {
 				showValidMoves = checked
-			}).
-			AddButton("Start Game", func() {
-				// Set AI flags based on player color
-				if playerColorOption == "Black" {
-					g.blackAI = false
-					g.whiteAI = true
-				} else {
-					g.blackAI = true
-					g.whiteAI = false
-				}
+			})
 
-				// Set difficulty (assuming difficulty levels map to some settings)
-				switch difficultyOption {
-				case "Easy":
-					g.difficulty = 2
-				case "Medium":
-					g.difficulty = 3
-				case "Hard":
-					g.difficulty = 5
-				case "Brutal":
-					g.difficulty = 7
-				case "Extreme":
-					g.difficulty = 9
-				default:
-					g.difficulty = 3 // Default to Medium
-				}
+		custom := startBoard != *NewBoard() || startPlayer != Black
+		if custom {
+			form.AddTextView("Start position", fmt.Sprintf("Set up, %s to move", g.PlayerName(startPlayer)), 0, 1, false, false)
+		}
 
-				startGame()
-			}).
-			AddButton("Quit", func() {
-				app.Stop()
+		form.
+			AddButton("Start Game", beginGame).
+			AddButton("Edit Board", func() {
+				showBoardEditor(app, startBoard, startPlayer, func(board Board, current int, analyze bool) {
+					startBoard, startPlayer = board, current
+
+					if analyze {
+						// Analysis is done by humans with hints on both sides, until the
+						// next visit to the start screen
+						analysisTypes = maps.Clone(playerTypes)
+						playerTypes[Black], playerTypes[White] = 0, 0
+						analyzeOnStart = true
+					}
+
+					beginGame()
+				}, showStartScreen)
 			})
 
+		if custom {
+			form.AddButton("Reset Board", func() {
+				startBoard, startPlayer = *NewBoard(), Black
+				showStartScreen()
+			})
+		}
+
+		form.AddButton("Quit", func() {
+			app.Stop()
+		})
+
 		form.SetBorder(true).SetTitle("Reversi").SetTitleAlign(tview.AlignCenter)
 
 		app.SetRoot(form, true).SetFocus(form)
@@ -73,7 +181,14 @@ func (g *Game) StartUI() {
 
 	// Now define startGame, which will set up the board and start the game
 	startGame = func() {
-		g.Reset()
+		g.SetPosition(&startBoard, startPlayer)
+
+		timeLoser = Blank
+		clocks = nil
+
+		if tc := timeControls[timeControl]; tc.Timed() {
+			clocks = map[int]*Clock{Black: NewClock(tc), White: NewClock(tc)}
+		}
 
 		boardTable := tview.NewTable()
 
@@ -84,28 +199,115 @@ func (g *Game) StartUI() {
 		boardTable.SetBorderColor(tcell.ColorGreen)
 		boardTable.SetBorders(true)
 
-		// Create a new TextView to display the score components
+		// Create a new TextView to display the players and their scores
 		scoreBox := tview.NewTextView()
 		scoreBox.SetBorder(true)
 		scoreBox.SetTitle("Score")
 
-		// Create a Flex layout to arrange board and scoreBox side by side
+		// Create a TextView for the progress of the AI search
+		searchBox := tview.NewTextView()
+		searchBox.SetBorder(true)
+		searchBox.SetTitle("Search")
+
+		// Create a TextView for the evaluation breakdown and graph
+		evalBox := tview.NewTextView()
+		evalBox.SetBorder(true)
+		evalBox.SetTitle("Evaluation")
+		evalBox.SetDynamicColors(true)
+
+		// Create a TextView for the opening explorer
+		explorerBox := tview.NewTextView()
+		explorerBox.SetBorder(true)
+		explorerBox.SetTitle("Opening explorer")
+
+		sidePanel := tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(scoreBox, 11, 0, false).
+			AddItem(searchBox, 6, 0, false).
+			AddItem(evalBox, 0, 2, false).
+			AddItem(explorerBox, 0, 1, false)
+
+		// Create a Flex layout to arrange board and side panel side by side
 		flex := tview.NewFlex().
 			AddItem(boardTable, 0, 1, true).
-			AddItem(scoreBox, 60, 1, false)
+			AddItem(sidePanel, 60, 1, false)
+
+		updateExplorer := func() {
+			if database == nil {
+				explorerBox.SetText("No database loaded\n\n[d] open a WTHOR .wtb file")
+
+				return
+			}
+
+			continuations := database.Continuations(g)
+			if len(continuations) == 0 {
+				explorerBox.SetText(fmt.Sprintf("%d games, none reached this position", len(database.Games)))
+
+				return
+			}
+
+			var sb strings.Builder
+			fmt.Fprintf(&sb, "Move  Games   Win  Draw  Loss\n")
+
+			for _, c := range continuations {
+				win, draw, loss := c.Percentages()
+				fmt.Fprintf(&sb, "%-4s %6d %4.0f%% %4.0f%% %4.0f%%\n", SquareName(c.X, c.Y), c.Games, win, draw, loss)
+			}
+
+			explorerBox.SetText(sb.String())
+		}
+
+		// Hint scores for the position they were computed for
+		var (
+			hints      []MoveScore
+			hintBoard  Board
+			hintPlayer int
+			cancelHint = func() {}
+		)
+
+		updateScore := func() {
+			blackScore, whiteScore := g.GetScore()
+			blackLine := fmt.Sprintf("Black  %-11s %2d", playerTypeNames()[playerTypes[Black]], blackScore)
+			whiteLine := fmt.Sprintf("White  %-11s %2d", playerTypeNames()[playerTypes[White]], whiteScore)
+
+			if clocks != nil {
+				now := time.Now()
+				blackLine += "  " + clocks[Black].Format(now)
+				whiteLine += "  " + clocks[White].Format(now)
+			}
+
+			status := fmt.Sprintf("AI move delay: %.1fs", moveDelay.Seconds())
+			if paused {
+				status += "  (paused)"
+			}
+
+			scoreText := fmt.Sprintf("%s\n%s\n%s\n\n"+
+				"[u] undo  [r] redo\n[s] save  [l] load  [b] opening book\n[h] hint  [+/-] difficulty\n"+
+				"[p] pause  [.] step  [ and ] move delay\n[n] new game  [q] quit",
+				blackLine, whiteLine, status)
+			scoreBox.SetText(scoreText)
+		}
+
+		updateEvaluation := func() {
+			_, _, width, _ := evalBox.GetInnerRect()
+			if width <= 0 {
+				width = 56
+			}
+
+			evalBox.SetText(formatEvaluation(g) + "\n" + evaluationGraph(g.EvaluationHistory(), width, 7))
+		}
 
 		updateBoard := func() {
 			for y := 0; y < BoardSize; y++ {
 				for x := 0; x < BoardSize; x++ {
-					symbol := getPieceSymbol(g.board[x][y])
+					symbol := getPieceSymbol(g.board.Get(x, y))
 
 					cell := tview.NewTableCell(symbol)
 					cell.SetAlign(tview.AlignCenter)
 
 					boardTable.SetCell(y, x, cell)
 
-					if g.board[x][y] == Blank && showValidMoves {
-						if flips := g.Flips(x, y, g.current); len(flips) > 0 {
+					if g.board.Get(x, y) == Blank && showValidMoves {
+						if flips := g.Flips(x, y, g.current); flips != 0 {
 							// Highlight valid moves
 							validCell := tview.NewTableCell("· ")
 							validCell.SetAlign(tview.AlignCenter)
@@ -116,22 +318,33 @@ func (g *Game) StartUI() {
 				}
 			}
 
+			// Annotate legal moves with their hint scores, best move highlighted
+			if hints != nil && hintBoard == *g.board && hintPlayer == g.current {
+				for i, hint := range hints {
+					hintCell := tview.NewTableCell(formatHintScore(hint))
+					hintCell.SetAlign(tview.AlignCenter)
+					hintCell.SetTextColor(tcell.ColorDarkCyan)
+
+					if i == 0 {
+						hintCell.SetTextColor(tcell.ColorYellow)
+						hintCell.SetAttributes(tcell.AttrBold)
+					}
+
+					boardTable.SetCell(hint.Move.Y, hint.Move.X, hintCell)
+				}
+			}
+
 			// Update the title with the current player
 			boardTable.SetTitle(fmt.Sprintf(" Reversi - %s's turn ", g.PlayerName(g.current)))
 
-			// Update the status box with the current score
-			blackScore, whiteScore := g.GetScore()
-			scoreText := fmt.Sprintf("Black: %d\nWhite: %d", blackScore, whiteScore)
-			scoreBox.SetText(scoreText)
+			updateScore()
+			updateEvaluation()
+			updateExplorer()
 		}
 
 		updateBoard()
 
-		var (
-			AIThinking   int32 // Atomic boolean for AI thinking status
-			spinnerIndex int
-			spinners     = []string{"|", "/", "-", "\\"}
-		)
+		var AIThinking int32 // Atomic boolean for AI thinking status
 
 		// Function to handle turns
 		var processNextTurn func()
@@ -147,7 +360,7 @@ func (g *Game) StartUI() {
 			// Check if current player has any valid moves
 			if len(g.ValidMoves(g.current)) == 0 {
 				// Current player has no valid moves
-				g.SwitchTurn()
+				g.Pass()
 				updateBoard()
 				// Process next turn
 				processNextTurn()
@@ -155,40 +368,120 @@ func (g *Game) StartUI() {
 				return
 			}
 
-			if (g.current == Black && g.blackAI) || (g.current == White && g.whiteAI) {
+			if g.IsAI(g.current) && paused {
+				if !step {
+					updateBoard()
+					boardTable.SetTitle(fmt.Sprintf(" Reversi - %s's turn (paused) ", g.PlayerName(g.current)))
+
+					return
+				}
+
+				step = false
+			}
+
+			startClock()
+
+			if g.IsAI(g.current) {
 				// AI's turn
+				ctx, cancel := context.WithCancel(context.Background())
+				cancelSearch = func() {
+					cancel()
+					atomic.StoreInt32(&AIThinking, 0)
+				}
+
 				atomic.StoreInt32(&AIThinking, 1)
-				spinnerIndex = 0
 
-				// Start the spinner goroutine
-				ticker := time.NewTicker(100 * time.Millisecond)
+				boardTable.SetTitle(fmt.Sprintf(" Reversi - %s's turn (thinking) ", g.PlayerName(g.current)))
+				searchBox.SetText("Searching...")
+
+				// Search a copy of the game so that a cancelled search can never touch the board
+				position := g.Copy()
+
+				if clocks != nil {
+					// Let the clock rather than the depth limit the search
+					engine := position.Engine(g.current)
+					engine.MoveTime = clocks[g.current].MoveBudget(time.Now(), g.CountEmptySquares())
+					engine.Depth = BoardSize * BoardSize
+				}
+
+				// The search publishes its progress from its own goroutine; keep the
+				// latest report and redraw it periodically
+				var latest atomic.Pointer[SearchInfo]
+				position.progress = func(info SearchInfo) {
+					latest.Store(&info)
+				}
+
 				go func() {
+					ticker := time.NewTicker(100 * time.Millisecond)
+					defer ticker.Stop()
+
 					for {
 						select {
+						case <-ctx.Done():
+							return
 						case <-ticker.C:
-							if atomic.LoadInt32(&AIThinking) == 0 {
-								ticker.Stop()
-
-								return
+							info := latest.Load()
+							if info == nil {
+								continue
 							}
-							spinner := spinners[spinnerIndex%len(spinners)]
-							spinnerIndex++
+
 							app.QueueUpdateDraw(func() {
-								boardTable.SetTitle(fmt.Sprintf(" Reversi - %s's turn %s ", g.PlayerName(g.current), spinner))
+								if ctx.Err() == nil {
+									searchBox.SetText(formatSearchInfo(*info))
+								}
 							})
 						}
 					}
 				}()
 
-				// Start the AI move in a goroutine
 				go func() {
-					g.AIMove()
-
-					atomic.StoreInt32(&AIThinking, 0)
+					move := position.BestMove(ctx)
 
 					app.QueueUpdateDraw(func() {
+						if ctx.Err() != nil {
+							// Cancelled by new game, quit, undo or a difficulty change
+							return
+						}
+
+						cancelSearch()
+
+						if !stopClocks() {
+							gameOver()
+
+							return
+						}
+
+						if info := latest.Load(); info != nil {
+							searchBox.SetText(formatSearchInfo(*info))
+						} else {
+							searchBox.SetText("Book move")
+						}
+
+						g.Play(move)
 						updateBoard()
-						boardTable.SetTitle(fmt.Sprintf(" Reversi - %s's turn ", g.PlayerName(g.current)))
+
+						// Give spectators of AI-vs-AI games time to follow each move
+						if g.blackAI && g.whiteAI && moveDelay > 0 && !g.IsGameOver() {
+							delayCtx, cancelDelay := context.WithCancel(context.Background())
+							cancelSearch = func() {
+								cancelDelay()
+								atomic.StoreInt32(&AIThinking, 0)
+							}
+
+							atomic.StoreInt32(&AIThinking, 1)
+
+							time.AfterFunc(moveDelay, func() {
+								app.QueueUpdateDraw(func() {
+									if delayCtx.Err() == nil {
+										cancelSearch()
+										processNextTurn()
+									}
+								})
+							})
+
+							return
+						}
+
 						// After the AI move, process the next turn
 						processNextTurn()
 					})
@@ -199,18 +492,56 @@ func (g *Game) StartUI() {
 			}
 		}
 
+		// showHints scores every legal move in the background, leaving the board usable
+		showHints := func() {
+			if g.IsAI(g.current) {
+				return
+			}
+
+			cancelHint()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancelHint = cancel
+			hints, hintBoard, hintPlayer = nil, *g.board, g.current
+			position := g.Copy()
+
+			boardTable.SetTitle(fmt.Sprintf(" Reversi - %s's turn (computing hint) ", g.PlayerName(g.current)))
+
+			go func() {
+				scores := position.AnalyzeMoves(ctx)
+
+				app.QueueUpdateDraw(func() {
+					if ctx.Err() != nil {
+						return
+					}
+
+					cancel()
+					hints = scores
+					updateBoard()
+				})
+			}()
+		}
+
 		boardTable.SetSelectedFunc(func(row, column int) {
 			// Block input if AI is thinking
 			if atomic.LoadInt32(&AIThinking) == 1 {
 				return
 			}
 
-			if g.board[column][row] != Blank {
+			if g.board.Get(column, row) != Blank {
 				return
 			}
 
-			if flips := g.Flips(column, row, g.current); len(flips) > 0 {
-				g.MakeMove(Move{X: column, Y: row, Flips: flips}, true)
+			if flips := g.Flips(column, row, g.current); flips != 0 {
+				cancelHint()
+
+				if !stopClocks() {
+					gameOver()
+
+					return
+				}
+
+				g.Play(Move{X: column, Y: row, Flips: flips})
 				updateBoard()
 
 				// Process the next turn
@@ -221,15 +552,305 @@ func (g *Game) StartUI() {
 			}
 		})
 
-		if g.current == Black && g.blackAI {
-			// If it's AI's turn, start the AI move
-			processNextTurn()
+		showBoard := func() {
+			app.SetRoot(flex, true).SetFocus(boardTable)
+		}
+
+		// promptFile asks for a file name and hands it to action, reporting any error
+		promptFile := func(label, defaultPath string, action func(path string) error) {
+			form := tview.NewForm()
+			form.
+				AddInputField("File", defaultPath, 40, nil, nil).
+				AddButton(label, func() {
+					path := form.GetFormItem(0).(*tview.InputField).GetText()
+
+					if err := action(path); err != nil {
+						modal := tview.NewModal().
+							SetText(fmt.Sprintf("%s failed:\n%v", label, err)).
+							AddButtons([]string{"OK"}).
+							SetDoneFunc(func(buttonIndex int, buttonLabel string) {
+								showBoard()
+							})
+						app.SetRoot(modal, false).SetFocus(modal)
+
+						return
+					}
+
+					showBoard()
+
+					if !pauseClocks() {
+						gameOver()
+
+						return
+					}
+
+					updateBoard()
+					processNextTurn()
+				}).
+				AddButton("Cancel", showBoard)
+
+			form.SetBorder(true).SetTitle(fmt.Sprintf(" %s ", label)).SetTitleAlign(tview.AlignCenter)
+
+			app.SetRoot(form, true).SetFocus(form)
+		}
+
+		boardTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+			// These commands interrupt the AI if it is thinking
+			switch event.Rune() {
+			case 'n':
+				cancelSearch()
+				cancelHint()
+				stopClockTicker()
+				showStartScreen()
+
+				return nil
+			case 'q':
+				cancelSearch()
+				cancelHint()
+				stopClockTicker()
+				app.Stop()
+
+				return nil
+			case '+', '-':
+				// Adjust the AI to move, or else the human's AI opponent
+				player := g.current
+				if !g.IsAI(player) {
+					player = Opponent(player)
+				}
+
+				if !g.IsAI(player) {
+					return nil
+				}
+
+				if event.Rune() == '+' && playerTypes[player] < len(DifficultyLevels) {
+					playerTypes[player]++
+				} else if event.Rune() == '-' && playerTypes[player] > 1 {
+					playerTypes[player]--
+				}
+
+				g.SetDifficulty(player, playerTypes[player]-1)
+
+				// Restart a running search with the new settings
+				if atomic.LoadInt32(&AIThinking) == 1 {
+					cancelSearch()
+					processNextTurn()
+				}
+
+				updateScore()
+
+				return nil
+			case 'p':
+				paused = !paused
+
+				if !paused && atomic.LoadInt32(&AIThinking) == 0 {
+					processNextTurn()
+				}
+
+				updateScore()
+
+				return nil
+			case '[', ']':
+				if event.Rune() == ']' {
+					moveDelay += 250 * time.Millisecond
+				} else if moveDelay > 0 {
+					moveDelay -= 250 * time.Millisecond
+				}
+
+				updateScore()
+
+				return nil
+			case 'u':
+				// Take back moves until it is a human player's turn again
+				if !g.hasHumanMove() {
+					return nil
+				}
+
+				cancelSearch()
+				cancelHint()
+
+				if !pauseClocks() {
+					gameOver()
+
+					return nil
+				}
+
+				// A human's pass is not a turn to return to
+				for g.Undo() {
+					if !g.IsAI(g.current) && len(g.ValidMoves(g.current)) > 0 {
+						break
+					}
+				}
+
+				updateBoard()
+				processNextTurn()
+
+				return nil
+			}
+
+			// Block the remaining key commands if AI is thinking
+			if atomic.LoadInt32(&AIThinking) == 1 {
+				return event
+			}
+
+			switch event.Rune() {
+			case '.':
+				// Play a single AI move while paused
+				if paused && g.IsAI(g.current) {
+					step = true
+					processNextTurn()
+				}
+
+				return nil
+			case 'h':
+				showHints()
+
+				return nil
+			case 'r':
+				// Replay undone moves up to the next human turn
+				if !pauseClocks() {
+					gameOver()
+
+					return nil
+				}
+
+				for g.Redo() {
+					if !g.IsAI(g.current) {
+						break
+					}
+				}
+
+				updateBoard()
+				processNextTurn()
+
+				return nil
+			case 's':
+				promptFile("Save game", "game.txt", func(path string) error {
+					text := g.Transcript()
+					if !g.StartsFromInitial() {
+						// A transcript cannot describe a set-up start position
+						text = NewGGFRecord(g).String()
+					}
+
+					switch strings.ToLower(filepath.Ext(path)) {
+					case ".ggf":
+						text = NewGGFRecord(g).String()
+					case ".obf":
+						text = g.Position()
+					}
+
+					return os.WriteFile(path, []byte(text+"\n"), 0o644)
+				})
+
+				return nil
+			case 'l':
+				promptFile("Load game", "game.txt", func(path string) error {
+					data, err := os.ReadFile(path)
+					if err != nil {
+						return err
+					}
+
+					switch strings.ToLower(filepath.Ext(path)) {
+					case ".ggf":
+						return g.LoadGGF(string(data))
+					case ".obf":
+						return g.LoadPosition(string(data))
+					}
+
+					if strings.HasPrefix(strings.TrimSpace(string(data)), "(;") {
+						return g.LoadGGF(string(data))
+					}
+
+					return g.LoadTranscript(string(data))
+				})
+
+				return nil
+			case 'b':
+				promptFile("Load opening book", "book.txt", func(path string) error {
+					f, err := os.Open(path)
+					if err != nil {
+						return err
+					}
+					defer f.Close()
+
+					book, err := LoadBook(f)
+					if err != nil {
+						return err
+					}
+
+					g.book = book
+
+					return nil
+				})
+
+				return nil
+			case 'd':
+				promptFile("Open database", "WTH_2000.wtb", func(path string) error {
+					db, err := OpenWthorDatabase(path)
+					if err != nil {
+						return err
+					}
+
+					database = db
+
+					return nil
+				})
+
+				return nil
+			}
+
+			return event
+		})
+
+		// Tick the clocks and end the game when the player to move runs out of time
+		if clocks != nil {
+			ctx, cancel := context.WithCancel(context.Background())
+			stopClockTicker = cancel
+
+			go func() {
+				ticker := time.NewTicker(100 * time.Millisecond)
+				defer ticker.Stop()
+
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+						app.QueueUpdateDraw(func() {
+							if ctx.Err() != nil {
+								return
+							}
+
+							if clocks[g.current].Expired(time.Now()) {
+								cancelSearch()
+								cancelHint()
+								stopClocks()
+								gameOver()
+
+								return
+							}
+
+							updateScore()
+						})
+					}
+				}
+			}()
+		}
+
+		// Start the first turn, which starts the clock and lets an AI move
+		processNextTurn()
+
+		if analyzeOnStart {
+			analyzeOnStart = false
+			showHints()
 		}
 
 		app.SetRoot(flex, true)
 	}
 
 	gameOver = func() {
+		stopClockTicker()
+		stopClocks()
+
 		var winner string
 
 		switch g.GetWinner() {
@@ -240,7 +861,11 @@ func (g *Game) StartUI() {
 		default:
 			winner = "It's a draw!"
 		}
-		
+
+		if timeLoser != Blank {
+			winner = fmt.Sprintf("%s wins on time!", g.PlayerName(Opponent(timeLoser)))
+		}
+
 		// Create the ASCII art
 		asciiArt := `
 ⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⢀⣀⣠⣴⣶⣶⣶⣶⣾⣿⣿⣶⣶⣶⣤⣀⣀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀⠀
@@ -290,11 +915,124 @@ func (g *Game) StartUI() {
 
 	showStartScreen()
 
-	if err := app.Run(); err != nil {
+	err := app.Run()
+
+	// Stop any search still running in the background
+	cancelSearch()
+	stopClockTicker()
+
+	if err != nil {
 		panic(err)
 	}
 }
 
+// playerTypeNames returns the choices for each side on the start screen: a human,
+// or the AI at one of the difficulty levels
+func playerTypeNames() []string {
+	names := []string{"Human"}
+
+	for _, name := range difficultyNames() {
+		names = append(names, "AI "+name)
+	}
+
+	return names
+}
+
+// formatEvaluation lays out the evaluation components of both players side by
+// side with the weights of the current game phase
+func formatEvaluation(g *Game) string {
+	black := g.EvaluateDetailed(Black)
+	white := g.EvaluateDetailed(White)
+
+	rows := []struct {
+		name                 string
+		weight, black, white float64
+	}{
+		{"Heuristic", black.WeightHeuristic, black.Heuristic, white.Heuristic},
+		{"Disc difference", black.WeightDiscDifference, black.DiscDiff, white.DiscDiff},
+		{"Mobility", black.WeightMobility, black.Mobility, white.Mobility},
+		{"Frontier", black.WeightFrontier, black.Frontier, white.Frontier},
+		{"Potential mobility", black.WeightPotentialMob, black.PotentialMobility, white.PotentialMobility},
+		{"Corners", black.WeightCorner, black.CornerOwnership, white.CornerOwnership},
+		{"Edges", black.WeightEdge, black.EdgeStability, white.EdgeStability},
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Phase: %s (%d empty)\n\n", g.GetGamePhase(), g.CountEmptySquares())
+	fmt.Fprintf(&sb, "%-18s %6s %9s %9s\n", "Component", "Weight", "Black", "White")
+
+	for _, row := range rows {
+		fmt.Fprintf(&sb, "%-18s %6.1f %9.1f %9.1f\n", row.name, row.weight, row.black, row.white)
+	}
+
+	fmt.Fprintf(&sb, "%-18s %6s %9.1f %9.1f\n", "Total", "", black.TotalScore, white.TotalScore)
+
+	return sb.String()
+}
+
+// evaluationGraph draws Black's evaluation after every ply as a bar chart of the
+// given height around a zero line, scaled to the largest evaluation so far. Bars
+// above the line favour Black, bars below favour White. Only the most recent
+// plies are drawn when there are more than width.
+func evaluationGraph(evals []float64, width, height int) string {
+	if len(evals) > width {
+		evals = evals[len(evals)-width:]
+	}
+
+	scale := 1.0
+	for _, eval := range evals {
+		scale = max(scale, math.Abs(eval))
+	}
+
+	half := height / 2
+	var sb strings.Builder
+
+	for row := half; row >= -half; row-- {
+		for _, eval := range evals {
+			bar := int(math.Round(eval / scale * float64(half)))
+
+			switch {
+			case row == 0:
+				sb.WriteString("[gray]─")
+			case row > 0 && bar >= row:
+				sb.WriteString("[white]█")
+			case row < 0 && bar <= row:
+				sb.WriteString("[darkcyan]█")
+			default:
+				sb.WriteString(" ")
+			}
+		}
+
+		sb.WriteString("[-]\n")
+	}
+
+	fmt.Fprintf(&sb, "Black %+.1f  (range ±%.0f)", evals[len(evals)-1], scale)
+
+	return sb.String()
+}
+
+// formatSearchInfo describes the progress of a search in a few short lines
+func formatSearchInfo(info SearchInfo) string {
+	return fmt.Sprintf("Depth %d  Move %s  Score %s\nNodes %d  %.0f kN/s  %.1fs\nPV %s",
+		info.Depth, SquareName(info.Move.X, info.Move.Y), formatHintScore(MoveScore{Score: info.Score, Solved: info.Solved}),
+		info.Nodes, info.NodesPerSecond()/1000, info.Elapsed.Seconds(), info.PVString())
+}
+
+// formatHintScore shortens a hint score to fit in a board cell: exact disc
+// differences for solved moves, otherwise the evaluation in hundreds
+func formatHintScore(hint MoveScore) string {
+	switch {
+	case hint.Solved:
+		return fmt.Sprintf("%+.0f", hint.Score)
+	case hint.Score >= winScore/2:
+		return "win"
+	case hint.Score <= -winScore/2:
+		return "loss"
+	default:
+		return fmt.Sprintf("%+.1f", hint.Score/100)
+	}
+}
+
 func getPieceSymbol(piece int) string {
 	switch piece {
 	case Black:
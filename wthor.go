@@ -0,0 +1,323 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// WTHOR is the binary database format of the Fédération Française d'Othello.
+// Every file starts with a 16 byte header followed by fixed-size records: 68 byte
+// games in .wtb files, 20 byte player names in WTHOR.JOU and 26 byte tournament
+// names in WTHOR.TRN. Games refer to players and tournaments by index.
+const (
+	wthorHeaderSize     = 16
+	wthorGameSize       = 68
+	wthorPlayerSize     = 20
+	wthorTournamentSize = 26
+)
+
+// WthorGame is a single game of a WTHOR database
+type WthorGame struct {
+	Tournament       string
+	BlackPlayer      string
+	WhitePlayer      string
+	Year             int
+	BlackScore       int   // Discs owned by Black at the end of the game
+	TheoreticalScore int   // Black's score with perfect play from the final empties
+	Moves            []int // Squares in bitboard order, passes are implicit
+}
+
+// WthorDatabase holds the games of one or more .wtb files
+type WthorDatabase struct {
+	Games []WthorGame
+}
+
+// OpenWthorDatabase loads a .wtb file, resolving names from WTHOR.JOU and
+// WTHOR.TRN if they are found in the same directory
+func OpenWthorDatabase(path string) (*WthorDatabase, error) {
+	dir := filepath.Dir(path)
+
+	players, err := readWthorNamesFile(dir, "WTHOR.JOU", wthorPlayerSize)
+	if err != nil {
+		return nil, err
+	}
+
+	tournaments, err := readWthorNamesFile(dir, "WTHOR.TRN", wthorTournamentSize)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	games, err := ReadWthorGames(f, players, tournaments)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return &WthorDatabase{Games: games}, nil
+}
+
+// readWthorNamesFile reads an optional name file from dir, matching its name case-insensitively
+func readWthorNamesFile(dir, name string, recordSize int) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if !strings.EqualFold(entry.Name(), name) {
+			continue
+		}
+
+		f, err := os.Open(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		names, err := ReadWthorNames(f, recordSize)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+
+		return names, nil
+	}
+
+	return nil, nil
+}
+
+// ReadWthorNames reads a player (.JOU) or tournament (.TRN) file with the given record size
+func ReadWthorNames(r io.Reader, recordSize int) ([]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < wthorHeaderSize {
+		return nil, fmt.Errorf("file too short for a WTHOR header")
+	}
+
+	var names []string
+
+	for rec := data[wthorHeaderSize:]; len(rec) >= recordSize; rec = rec[recordSize:] {
+		names = append(names, decodeLatin1(rec[:recordSize]))
+	}
+
+	return names, nil
+}
+
+// decodeLatin1 converts a NUL-terminated ISO 8859-1 string
+func decodeLatin1(b []byte) string {
+	var sb strings.Builder
+
+	for _, c := range b {
+		if c == 0 {
+			break
+		}
+
+		sb.WriteRune(rune(c))
+	}
+
+	return strings.TrimSpace(sb.String())
+}
+
+// ReadWthorGames reads the games of a .wtb file. Player and tournament names are
+// looked up in the given tables; unknown indices are shown as "#n".
+func ReadWthorGames(r io.Reader, players, tournaments []string) ([]WthorGame, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < wthorHeaderSize {
+		return nil, fmt.Errorf("file too short for a WTHOR header")
+	}
+
+	header := data[:wthorHeaderSize]
+	count := int(binary.LittleEndian.Uint32(header[4:8]))
+	year := int(binary.LittleEndian.Uint16(header[10:12]))
+
+	if size := header[12]; size != 0 && size != BoardSize {
+		return nil, fmt.Errorf("unsupported board size %d", size)
+	}
+
+	records := data[wthorHeaderSize:]
+	if len(records) < count*wthorGameSize {
+		return nil, fmt.Errorf("header announces %d games but file holds %d", count, len(records)/wthorGameSize)
+	}
+
+	games := make([]WthorGame, 0, count)
+
+	for i := 0; i < count; i++ {
+		rec := records[i*wthorGameSize : (i+1)*wthorGameSize]
+
+		game := WthorGame{
+			Tournament:       lookupWthorName(tournaments, binary.LittleEndian.Uint16(rec[0:2])),
+			BlackPlayer:      lookupWthorName(players, binary.LittleEndian.Uint16(rec[2:4])),
+			WhitePlayer:      lookupWthorName(players, binary.LittleEndian.Uint16(rec[4:6])),
+			Year:             year,
+			BlackScore:       int(rec[6]),
+			TheoreticalScore: int(rec[7]),
+		}
+
+		for _, m := range rec[8:] {
+			if m == 0 {
+				break
+			}
+
+			// Moves are stored as 10*row + column, both counted from 1
+			x, y := int(m%10)-1, int(m/10)-1
+			if x < 0 || x >= BoardSize || y < 0 || y >= BoardSize {
+				return nil, fmt.Errorf("game %d: invalid move byte %d", i+1, m)
+			}
+
+			game.Moves = append(game.Moves, y*BoardSize+x)
+		}
+
+		games = append(games, game)
+	}
+
+	return games, nil
+}
+
+func lookupWthorName(names []string, index uint16) string {
+	if int(index) < len(names) {
+		return names[index]
+	}
+
+	return fmt.Sprintf("#%d", index)
+}
+
+// Game replays the game from the initial position, inserting passes where needed
+func (w *WthorGame) Game() (*Game, error) {
+	g := NewGame()
+
+	for i, sq := range w.Moves {
+		x, y := squareXY(sq)
+
+		if err := g.playSquare(x, y); err != nil {
+			return nil, fmt.Errorf("move %d: %w", i+1, err)
+		}
+	}
+
+	g.passIfStuck()
+
+	return g, nil
+}
+
+// Continuation summarizes the database games that continued from a position with
+// the move (X, Y). Results are counted from the point of view of the side to move.
+type Continuation struct {
+	X, Y   int
+	Games  int
+	Wins   int
+	Draws  int
+	Losses int
+}
+
+// Percentages returns the share of wins, draws and losses
+func (c Continuation) Percentages() (float64, float64, float64) {
+	if c.Games == 0 {
+		return 0, 0, 0
+	}
+
+	total := float64(c.Games)
+
+	return 100 * float64(c.Wins) / total, 100 * float64(c.Draws) / total, 100 * float64(c.Losses) / total
+}
+
+// Continuations lists the moves played from the current position of g in the
+// database, most frequent first
+func (db *WthorDatabase) Continuations(g *Game) []Continuation {
+	target := *g.board
+	discs := popCount(target.black | target.white)
+	stats := make(map[int]*Continuation)
+
+	for _, game := range db.Games {
+		pos := *NewBoard()
+		player := Black
+
+		for _, sq := range game.Moves {
+			own, opp := pos.Discs(player), pos.Discs(Opponent(player))
+
+			if legalMoves(own, opp) == 0 {
+				player = Opponent(player)
+				own, opp = opp, own
+			}
+
+			// Every move adds exactly one disc, so the position can only match at this ply
+			if popCount(pos.black|pos.white) == discs {
+				if pos == target && player == g.current {
+					c, ok := stats[sq]
+					if !ok {
+						x, y := squareXY(sq)
+						c = &Continuation{X: x, Y: y}
+						stats[sq] = c
+					}
+
+					c.Games++
+
+					switch ownScore := game.scoreFor(player); {
+					case ownScore > 32:
+						c.Wins++
+					case ownScore < 32:
+						c.Losses++
+					default:
+						c.Draws++
+					}
+				}
+
+				break
+			}
+
+			flips := flipsFor(own, opp, sq)
+			if flips == 0 || (own|opp)&(1<<uint(sq)) != 0 {
+				break // Corrupt game record
+			}
+
+			placed := uint64(1)<<uint(sq) | flips
+			if player == Black {
+				pos.black |= placed
+				pos.white &^= flips
+			} else {
+				pos.white |= placed
+				pos.black &^= flips
+			}
+
+			player = Opponent(player)
+		}
+	}
+
+	continuations := make([]Continuation, 0, len(stats))
+	for _, c := range stats {
+		continuations = append(continuations, *c)
+	}
+
+	sort.Slice(continuations, func(i, j int) bool {
+		if continuations[i].Games != continuations[j].Games {
+			return continuations[i].Games > continuations[j].Games
+		}
+
+		return continuations[i].Y*BoardSize+continuations[i].X < continuations[j].Y*BoardSize+continuations[j].X
+	})
+
+	return continuations
+}
+
+// scoreFor returns the final disc count of player
+func (w *WthorGame) scoreFor(player int) int {
+	if player == Black {
+		return w.BlackScore
+	}
+
+	return BoardSize*BoardSize - w.BlackScore
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// wthorFile builds a .wtb file holding one record per game. Each game is given
+// as Black's final disc count followed by its moves as WTHOR move bytes.
+func wthorFile(year int, games ...[]byte) []byte {
+	header := make([]byte, wthorHeaderSize)
+	binary.LittleEndian.PutUint32(header[4:8], uint32(len(games)))
+	binary.LittleEndian.PutUint16(header[10:12], uint16(year))
+	header[12] = BoardSize
+
+	data := bytes.NewBuffer(header)
+
+	for i, game := range games {
+		record := make([]byte, wthorGameSize)
+		binary.LittleEndian.PutUint16(record[0:2], 0)           // Tournament
+		binary.LittleEndian.PutUint16(record[2:4], uint16(i))   // Black player
+		binary.LittleEndian.PutUint16(record[4:6], uint16(i+1)) // White player
+		record[6], record[7] = game[0], game[0]
+		copy(record[8:], game[1:])
+		data.Write(record)
+	}
+
+	return data.Bytes()
+}
+
+// wthorNames builds a name file with the given record size
+func wthorNames(recordSize int, names ...string) []byte {
+	data := make([]byte, wthorHeaderSize)
+
+	for _, name := range names {
+		record := make([]byte, recordSize)
+		copy(record, name)
+		data = append(data, record...)
+	}
+
+	return data
+}
+
+func TestReadWthorGames(t *testing.T) {
+	// f5 d6 c3 won by Black 40-24, and f5 f6 won by White 20-44
+	file := wthorFile(2001, []byte{40, 56, 64, 33}, []byte{20, 56, 66})
+
+	players, err := ReadWthorNames(bytes.NewReader(wthorNames(wthorPlayerSize, "Alice", "Bob")), wthorPlayerSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	games, err := ReadWthorGames(bytes.NewReader(file), players, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(games) != 2 {
+		t.Fatalf("read %d games, want 2", len(games))
+	}
+
+	first := games[0]
+	if first.BlackPlayer != "Alice" || first.WhitePlayer != "Bob" || first.Tournament != "#0" || first.Year != 2001 || first.BlackScore != 40 {
+		t.Errorf("first game = %+v", first)
+	}
+
+	if games[1].WhitePlayer != "#2" {
+		t.Errorf("unknown player = %q, want #2", games[1].WhitePlayer)
+	}
+
+	g, err := first.Game()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := g.Transcript(); got != "f5d6c3" {
+		t.Errorf("replayed moves = %q, want f5d6c3", got)
+	}
+
+	db := &WthorDatabase{Games: games}
+
+	tests := []struct {
+		transcript string
+		want       []Continuation
+	}{
+		{"", []Continuation{{X: 5, Y: 4, Games: 2, Wins: 1, Losses: 1}}},
+		// White to move, so the results are White's
+		{"f5", []Continuation{{X: 3, Y: 5, Games: 1, Losses: 1}, {X: 5, Y: 5, Games: 1, Wins: 1}}},
+		{"f5d6c3", nil},
+		{"d3", nil},
+	}
+
+	for _, test := range tests {
+		g := NewGame()
+		if err := g.LoadTranscript(test.transcript); err != nil {
+			t.Fatal(err)
+		}
+
+		got := db.Continuations(g)
+		if len(got) != len(test.want) {
+			t.Errorf("Continuations after %q = %+v, want %+v", test.transcript, got, test.want)
+
+			continue
+		}
+
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("Continuations after %q = %+v, want %+v", test.transcript, got, test.want)
+
+				break
+			}
+		}
+	}
+}
+
+func TestReadWthorGamesErrors(t *testing.T) {
+	valid := wthorFile(2001, []byte{40, 56})
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"short header", valid[:10]},
+		{"missing game", valid[:wthorHeaderSize+wthorGameSize-1]},
+		{"invalid move", wthorFile(2001, []byte{40, 56, 90})},
+		{"board size", append(append([]byte(nil), valid[:12]...), append([]byte{10}, valid[13:]...)...)},
+	}
+
+	for _, test := range tests {
+		if _, err := ReadWthorGames(bytes.NewReader(test.data), nil, nil); err == nil {
+			t.Errorf("%s: ReadWthorGames succeeded", test.name)
+		}
+	}
+}
@@ -1,35 +1,51 @@
 package main
 
-import (
-	"math/rand"
-)
-
-var zobristTable [BoardSize][BoardSize][3]uint64
-var zobristTurn uint64
-
-func initZobrist() {
-	for x := 0; x < BoardSize; x++ {
-		for y := 0; y < BoardSize; y++ {
-			for k := 0; k < 3; k++ {
-				zobristTable[x][y][k] = rand.Uint64()
-			}
+import "math/bits"
+
+// zobristSeed fixes the Zobrist keys, so that hashes are the same in every run
+const zobristSeed = 0x5265766572736921
+
+// zobristTable holds one key per colour (Black-1, White-1) and square. The keys
+// are generated once and never change, so they can be read from any goroutine.
+var zobristTable, zobristTurn = zobristKeys()
+
+// zobristKeys generates the keys with the splitmix64 generator
+func zobristKeys() ([2][BoardSize * BoardSize]uint64, uint64) {
+	var table [2][BoardSize * BoardSize]uint64
+
+	state := uint64(zobristSeed)
+	next := func() uint64 {
+		state += 0x9e3779b97f4a7c15
+
+		return mix64(state)
+	}
+
+	for c := range table {
+		for sq := range table[c] {
+			table[c][sq] = next()
 		}
 	}
 
-	zobristTurn = rand.Uint64()
+	return table, next()
 }
 
 func (g *Game) computeZobristHash() uint64 {
+	return hashBoard(g.board, g.current)
+}
+
+// hashBoard computes the Zobrist hash of a board with the given side to move
+func hashBoard(b *Board, current int) uint64 {
 	var h uint64
 
-	for x := 0; x < BoardSize; x++ {
-		for y := 0; y < BoardSize; y++ {
-			piece := g.board[x][y]
-			h ^= zobristTable[x][y][piece]
+	for c, discs := range [2]uint64{b.black, b.white} {
+		for discs != 0 {
+			sq := bits.TrailingZeros64(discs)
+			discs &= discs - 1
+			h ^= zobristTable[c][sq]
 		}
 	}
 
-	if g.current == Black {
+	if current == Black {
 		h ^= zobristTurn
 	}
 